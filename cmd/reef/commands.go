@@ -0,0 +1,271 @@
+//------------------------------------------------------------------------------
+// Author: Lukasz Janyst <lukasz@jany.st>
+// Date: 17.10.2026
+//
+// Licensed under the GPL 3 License, see the LICENSE file for details.
+//------------------------------------------------------------------------------
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ljanyst/reef/pkg/importer"
+	"github.com/ljanyst/reef/pkg/reef"
+	log "github.com/sirupsen/logrus"
+)
+
+// Commands run instead of the web server when given after the global flags
+var commands = map[string]func(opts *reef.ReefOpts, args []string) error{
+	"export-sessions": exportSessions,
+	"export":          exportArchive,
+	"import":          importArchive,
+	"import-sessions": importSessions,
+	"migrate":         migrate,
+}
+
+// Open the output file, or the standard output if no file name is given
+func openOutput(fileName string) (io.WriteCloser, error) {
+	if fileName == "" || fileName == "-" {
+		return os.Stdout, nil
+	}
+	f, err := os.Create(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to create %s: %s", fileName, err)
+	}
+	return f, nil
+}
+
+// Split a comma separated list of names, ignoring the blanks around them
+func splitList(list string) []string {
+	names := []string{}
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func exportSessions(opts *reef.ReefOpts, args []string) error {
+	flags := flag.NewFlagSet("export-sessions", flag.ExitOnError)
+	from := flags.String("from", "", "first day to export, YYYY-MM-DD")
+	to := flags.String("to", "", "last day to export, YYYY-MM-DD")
+	tags := flags.String("tags", "", "comma separated tag names or ids")
+	format := flags.String("format", "csv", "output format, csv or json")
+	output := flags.String("output", "", "output file, the standard output by default")
+	flags.Parse(args)
+
+	db, err := reef.NewDatabase(&opts.Backend)
+	if err != nil {
+		return fmt.Errorf("Unable to initialize the database: %s", err)
+	}
+
+	filter, err := db.NewSessionFilter(*from, *to, splitList(*tags))
+	if err != nil {
+		return err
+	}
+
+	w, err := openOutput(*output)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	return db.ExportSessions(w, *format, filter)
+}
+
+func exportArchive(opts *reef.ReefOpts, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	output := flags.String("output", "", "output file, the standard output by default")
+	flags.Parse(args)
+
+	db, err := reef.NewDatabase(&opts.Backend)
+	if err != nil {
+		return fmt.Errorf("Unable to initialize the database: %s", err)
+	}
+
+	w, err := openOutput(*output)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	return db.ExportArchive(w)
+}
+
+func importArchive(opts *reef.ReefOpts, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: reef import archive.json\n")
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("Exactly one archive file is needed")
+	}
+
+	f, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return fmt.Errorf("Unable to open %s: %s", flags.Arg(0), err)
+	}
+	defer f.Close()
+
+	archive, err := reef.ReadArchive(f)
+	if err != nil {
+		return err
+	}
+
+	db, err := reef.NewDatabase(&opts.Backend)
+	if err != nil {
+		return fmt.Errorf("Unable to initialize the database: %s", err)
+	}
+
+	stats, err := db.ImportArchive(archive)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Imported %d tags, %d projects, %d milestones, %d tasks, %d sessions "+
+		"and %d goals", stats.Tags, stats.Projects, stats.Milestones, stats.Tasks,
+		stats.Sessions, stats.Goals)
+	return nil
+}
+
+func importSessions(opts *reef.ReefOpts, args []string) error {
+	flags := flag.NewFlagSet("import-sessions", flag.ExitOnError)
+	format := flags.String("format", "", "format of the export: toggl, clockify or watson")
+	dryRun := flags.Bool("dry-run", false, "only report what would be created")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: reef import-sessions -format toggl export.csv\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("Exactly one export file is needed")
+	}
+
+	periods, err := reef.NewPeriods(&opts.Backend)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return fmt.Errorf("Unable to open %s: %s", flags.Arg(0), err)
+	}
+	defer f.Close()
+
+	entries, err := importer.Read(*format, f, periods.Location)
+	if err != nil {
+		return err
+	}
+
+	db, err := reef.NewDatabase(&opts.Backend)
+	if err != nil {
+		return fmt.Errorf("Unable to initialize the database: %s", err)
+	}
+
+	plan, err := importer.Import(db, entries, *dryRun)
+	if err != nil {
+		return err
+	}
+
+	verb := "Created"
+	if *dryRun {
+		verb = "Would create"
+	}
+	for _, tag := range plan.Tags {
+		log.Infof("%s tag: %s", verb, tag)
+	}
+	for _, project := range plan.Projects {
+		log.Infof("%s project: %s (parent: %q, tags: %s)", verb, project.Title,
+			project.Parent, strings.Join(project.Tags, ", "))
+	}
+	log.Infof("%s %d sessions totalling %d minutes, skipped %d short entries", verb,
+		plan.Sessions, plan.Minutes, plan.Skipped)
+	for _, conflict := range plan.Conflicts {
+		log.Warnf("Conflict: %s", conflict)
+	}
+	return nil
+}
+
+func migrate(opts *reef.ReefOpts, args []string) error {
+	usage := "Usage: reef migrate status|up|down [-to version]\n"
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		return fmt.Errorf("The migration action is needed")
+	}
+
+	action := args[0]
+	flags := flag.NewFlagSet("migrate "+action, flag.ExitOnError)
+	to := flags.Uint64("to", 0, "target version, the latest one for up and the previous one for down")
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), usage)
+		flags.PrintDefaults()
+	}
+	flags.Parse(args[1:])
+	toSet := false
+	flags.Visit(func(f *flag.Flag) { toSet = toSet || f.Name == "to" })
+
+	db, err := reef.OpenDatabase(&opts.Backend)
+	if err != nil {
+		return fmt.Errorf("Unable to open the database: %s", err)
+	}
+
+	version, err := db.GetSchemaVersion()
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case "status":
+		statuses, err := db.GetMigrationStatus()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Schema version: %d of %d\n", version, db.GetLatestSchemaVersion())
+		for _, status := range statuses {
+			state := "pending"
+			if status.Applied {
+				state = "applied"
+				if status.AppliedAt != 0 {
+					state += " " + time.Unix(int64(status.AppliedAt), 0).Format("2006-01-02 15:04:05")
+				}
+			}
+			if !status.Reversible {
+				state += ", irreversible"
+			}
+			fmt.Printf("%4d  %-62s %s\n", status.Version, status.Name, state)
+		}
+		return nil
+	case "up":
+		if !toSet {
+			*to = db.GetLatestSchemaVersion()
+		}
+		if *to < version {
+			return fmt.Errorf("Version %d is older than the current version %d", *to, version)
+		}
+	case "down":
+		if !toSet {
+			if version == 0 {
+				return fmt.Errorf("There are no migrations to revert")
+			}
+			*to = version - 1
+		}
+		if *to > version {
+			return fmt.Errorf("Version %d is newer than the current version %d", *to, version)
+		}
+	default:
+		flags.Usage()
+		return fmt.Errorf("Unknown migration action: %s", action)
+	}
+
+	return db.Migrate(*to)
+}
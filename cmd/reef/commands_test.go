@@ -0,0 +1,83 @@
+//------------------------------------------------------------------------------
+// Author: Lukasz Janyst <lukasz@jany.st>
+// Date: 17.10.2026
+//
+// Licensed under the GPL 3 License, see the LICENSE file for details.
+//------------------------------------------------------------------------------
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ljanyst/reef/pkg/reef"
+)
+
+func TestSplitList(t *testing.T) {
+	cases := map[string][]string{
+		"":                 {},
+		"ops":              {"ops"},
+		"ops, internal":    {"ops", "internal"},
+		" clients ,, 3 , ": {"clients", "3"},
+		"with space,ops":   {"with space", "ops"},
+	}
+	for list, expected := range cases {
+		if actual := splitList(list); !reflect.DeepEqual(actual, expected) {
+			t.Errorf("Wrong split of %q: %q", list, actual)
+		}
+	}
+}
+
+func TestExportSessionsCommand(t *testing.T) {
+	dir, err := ioutil.TempDir("", "reef-commands")
+	if err != nil {
+		t.Fatalf("Unable to create a temporary directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	opts := &reef.ReefOpts{Backend: reef.BackendOpts{DatabaseDirectory: dir, TimeZone: "UTC"}}
+	db, err := reef.NewDatabase(&opts.Backend)
+	if err != nil {
+		t.Fatalf("Unable to create the database: %s", err)
+	}
+	now := uint64(time.Now().Unix())
+	steps := []func() error{
+		func() error { _, err := db.CreateTag("ops", "#00ff00", 0); return err },
+		func() error { _, err := db.CreateTag("internal", "#0000ff", 0); return err },
+		func() error { _, err := db.CreateTag("clients", "#ff0000", 0); return err },
+		func() error { _, err := db.CreateProject("Alpha", "", []uint64{1}, 0); return err },
+		func() error { _, err := db.CreateProject("Beta", "", []uint64{2}, 0); return err },
+		func() error { _, err := db.CreateProject("Gamma", "", []uint64{3}, 0); return err },
+		func() error { return db.AddSession(1, 0, 30, now-3600, "") },
+		func() error { return db.AddSession(2, 0, 45, now-3600, "") },
+		func() error { return db.AddSession(3, 0, 15, now-3600, "") },
+	}
+	for i, step := range steps {
+		if err := step(); err != nil {
+			t.Fatalf("Unable to populate the database, step %d: %s", i, err)
+		}
+	}
+
+	output := filepath.Join(dir, "sessions.csv")
+	args := []string{"-tags", "ops, internal,", "-output", output}
+	if err := exportSessions(opts, args); err != nil {
+		t.Fatalf("Unable to export the sessions: %s", err)
+	}
+	data, err := ioutil.ReadFile(output)
+	if err != nil {
+		t.Fatalf("Unable to read the export: %s", err)
+	}
+	projects := []string{}
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n")[1:] {
+		projects = append(projects, strings.Split(line, ",")[2])
+	}
+	if !reflect.DeepEqual(projects, []string{"Alpha", "Beta"}) {
+		t.Errorf("Wrong export:\n%s", data)
+	}
+}
@@ -61,5 +61,19 @@ func main() {
 		}
 	}
 
-	reef.RunWebServer(opts)
+	// Subcommands
+	args := flag.Args()
+	if len(args) == 0 {
+		reef.RunWebServer(opts)
+		return
+	}
+
+	command, ok := commands[args[0]]
+	if !ok {
+		log.Fatalf("Unknown command: %s", args[0])
+	}
+
+	if err := command(opts, args[1:]); err != nil {
+		log.Fatal(err)
+	}
 }
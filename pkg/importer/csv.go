@@ -0,0 +1,176 @@
+//------------------------------------------------------------------------------
+// Author: Lukasz Janyst <lukasz@jany.st>
+// Date: 17.10.2026
+//
+// Licensed under the GPL 3 License, see the LICENSE file for details.
+//------------------------------------------------------------------------------
+
+package importer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Column names of the detailed reports
+type csvLayout struct {
+	client      string
+	project     string
+	task        string
+	description string
+	tags        string
+	startDate   string
+	startTime   string
+	duration    string
+	dateFormats []string
+	timeFormats []string
+}
+
+var togglLayout = csvLayout{
+	client:      "client",
+	project:     "project",
+	task:        "task",
+	description: "description",
+	tags:        "tags",
+	startDate:   "start date",
+	startTime:   "start time",
+	duration:    "duration",
+	dateFormats: []string{"2006-01-02"},
+	timeFormats: []string{"15:04:05"},
+}
+
+var clockifyLayout = csvLayout{
+	client:      "client",
+	project:     "project",
+	task:        "task",
+	description: "description",
+	tags:        "tags",
+	startDate:   "start date",
+	startTime:   "start time",
+	duration:    "duration (h)",
+	dateFormats: []string{"01/02/2006", "2006-01-02", "02.01.2006"},
+	timeFormats: []string{"03:04:05 PM", "15:04:05", "03:04 PM", "15:04"},
+}
+
+func parseWithFormats(value string, formats []string) (time.Time, error) {
+	for _, format := range formats {
+		if t, err := time.Parse(format, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("Unrecognized date or time: %s", value)
+}
+
+// Parse a duration given as hh:mm:ss
+func parseClockDuration(value string) (time.Duration, error) {
+	parts := strings.Split(value, ":")
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("Malformed duration: %s", value)
+	}
+	var total time.Duration
+	units := []time.Duration{time.Hour, time.Minute, time.Second}
+	for i, part := range parts {
+		n, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("Malformed duration: %s", value)
+		}
+		total += time.Duration(n) * units[i]
+	}
+	return total, nil
+}
+
+func splitTags(value string) []string {
+	tags := []string{}
+	for _, tag := range strings.Split(value, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+func readCsv(r io.Reader, loc *time.Location, layout csvLayout) ([]Entry, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read the header: %s", err)
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.TrimPrefix(name, "\ufeff")
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{layout.project, layout.startDate, layout.startTime,
+		layout.duration} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("Missing column: %s", name)
+		}
+	}
+
+	entries := []Entry{}
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Unable to read line %d: %s", line, err)
+		}
+
+		field := func(name string) string {
+			if idx, ok := columns[name]; ok && idx < len(record) {
+				return strings.TrimSpace(record[idx])
+			}
+			return ""
+		}
+
+		date, err := parseWithFormats(field(layout.startDate), layout.dateFormats)
+		if err != nil {
+			return nil, fmt.Errorf("Line %d: %s", line, err)
+		}
+		clock, err := parseWithFormats(field(layout.startTime), layout.timeFormats)
+		if err != nil {
+			return nil, fmt.Errorf("Line %d: %s", line, err)
+		}
+		duration, err := parseClockDuration(field(layout.duration))
+		if err != nil {
+			return nil, fmt.Errorf("Line %d: %s", line, err)
+		}
+
+		note := field(layout.description)
+		if task := field(layout.task); task != "" {
+			if note == "" {
+				note = task
+			} else {
+				note = task + ": " + note
+			}
+		}
+
+		entries = append(entries, Entry{
+			Client:  field(layout.client),
+			Project: field(layout.project),
+			Tags:    splitTags(field(layout.tags)),
+			Start: time.Date(date.Year(), date.Month(), date.Day(), clock.Hour(),
+				clock.Minute(), clock.Second(), 0, loc),
+			Duration: duration,
+			Note:     note,
+		})
+	}
+	return entries, nil
+}
+
+// Read the detailed report exported from Toggl as CSV
+func ReadToggl(r io.Reader, loc *time.Location) ([]Entry, error) {
+	return readCsv(r, loc, togglLayout)
+}
+
+// Read the detailed report exported from Clockify as CSV
+func ReadClockify(r io.Reader, loc *time.Location) ([]Entry, error) {
+	return readCsv(r, loc, clockifyLayout)
+}
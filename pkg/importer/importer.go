@@ -0,0 +1,305 @@
+//------------------------------------------------------------------------------
+// Author: Lukasz Janyst <lukasz@jany.st>
+// Date: 17.10.2026
+//
+// Licensed under the GPL 3 License, see the LICENSE file for details.
+//------------------------------------------------------------------------------
+
+// Package importer brings the time entries exported from other time trackers
+// into Reef; clients become top-level projects, their projects become the
+// sub-projects and the time entries become work sessions
+package importer
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ljanyst/reef/pkg/reef"
+)
+
+// A time entry read from an export
+type Entry struct {
+	Client   string
+	Project  string
+	Tags     []string
+	Start    time.Time
+	Duration time.Duration
+	Note     string
+}
+
+type ProjectPlan struct {
+	Title  string   `json:"title"`
+	Parent string   `json:"parent"`
+	Tags   []string `json:"tags"`
+}
+
+// What an import creates; entries shorter than half a minute are skipped and
+// the conflicts with the existing projects prevent the import
+type Plan struct {
+	Tags      []string      `json:"tags"`
+	Projects  []ProjectPlan `json:"projects"`
+	Sessions  int           `json:"sessions"`
+	Minutes   uint64        `json:"minutes"`
+	Skipped   int           `json:"skipped"`
+	Conflicts []string      `json:"conflicts"`
+}
+
+// Color of the new tags; it can be changed in the UI afterwards
+const defaultTagColor = "#808080"
+
+var readers = map[string]func(io.Reader, *time.Location) ([]Entry, error){
+	"toggl":    ReadToggl,
+	"clockify": ReadClockify,
+	"watson":   ReadWatson,
+}
+
+// Read the entries of an export in one of the supported formats: toggl,
+// clockify or watson; the times without a zone are taken to be in loc
+func Read(format string, r io.Reader, loc *time.Location) ([]Entry, error) {
+	reader, ok := readers[format]
+	if !ok {
+		return nil, fmt.Errorf("Unknown import format: %s", format)
+	}
+	return reader(r, loc)
+}
+
+func minutes(d time.Duration) uint64 {
+	return uint64((d + 30*time.Second) / time.Minute)
+}
+
+func appendUnique(lst []string, elems ...string) []string {
+	for _, elem := range elems {
+		found := false
+		for _, e := range lst {
+			if e == elem {
+				found = true
+				break
+			}
+		}
+		if !found {
+			lst = append(lst, elem)
+		}
+	}
+	return lst
+}
+
+// Project of an entry in the tracker that exported it
+type projectKey struct {
+	title  string
+	parent string
+}
+
+func projectOf(entry Entry) projectKey {
+	if entry.Project == "" {
+		if entry.Client == "" {
+			return projectKey{"No project", ""}
+		}
+		return projectKey{entry.Client, ""}
+	}
+	return projectKey{entry.Project, entry.Client}
+}
+
+// Check that all the entries can be imported before anything is written
+func validate(entries []Entry) error {
+	for i, entry := range entries {
+		if entry.Start.Unix() < 0 {
+			return fmt.Errorf("Entry %d starts before 1970: %s", i+1, entry.Start)
+		}
+		for _, tag := range entry.Tags {
+			if tag == "" {
+				return fmt.Errorf("Entry %d has an empty tag", i+1)
+			}
+		}
+	}
+	return nil
+}
+
+// Work out what the import creates and the Reef titles of the projects. The
+// project titles are unique in Reef, so the projects that share their names
+// across clients, or with an existing project elsewhere in the tree, are
+// prefixed with the names of their clients; the existing projects under the
+// same parent are used as they are.
+func plan(db reef.Store, entries []Entry) (Plan, map[projectKey]string, error) {
+	p := Plan{Tags: []string{}, Projects: []ProjectPlan{}, Conflicts: []string{}}
+	if err := validate(entries); err != nil {
+		return Plan{}, nil, err
+	}
+
+	tags, err := db.GetTagList()
+	if err != nil {
+		return Plan{}, nil, err
+	}
+	knownTags := make(map[string]bool)
+	for _, tag := range tags {
+		knownTags[tag.Name] = true
+	}
+
+	summaries, err := db.GetSummaryList()
+	if err != nil {
+		return Plan{}, nil, err
+	}
+	projectTitles := make(map[uint64]string)
+	for _, summary := range summaries {
+		projectTitles[summary.Id] = summary.Title
+	}
+	// Titles of the parents of the existing projects
+	knownProjects := make(map[string]string)
+	for _, summary := range summaries {
+		knownProjects[summary.Title] = projectTitles[summary.ParentId]
+	}
+
+	keys := []projectKey{}
+	keyTags := make(map[projectKey][]string)
+	parentsOf := make(map[string]map[string]bool)
+	addKey := func(key projectKey, tags []string) {
+		if _, ok := keyTags[key]; !ok {
+			keys = append(keys, key)
+			keyTags[key] = []string{}
+			if parentsOf[key.title] == nil {
+				parentsOf[key.title] = make(map[string]bool)
+			}
+			parentsOf[key.title][key.parent] = true
+		}
+		keyTags[key] = appendUnique(keyTags[key], tags...)
+	}
+
+	for _, entry := range entries {
+		if minutes(entry.Duration) == 0 {
+			p.Skipped++
+			continue
+		}
+
+		for _, tag := range entry.Tags {
+			if !knownTags[tag] {
+				p.Tags = appendUnique(p.Tags, tag)
+			}
+		}
+
+		key := projectOf(entry)
+		if key.parent != "" {
+			addKey(projectKey{key.parent, ""}, []string{})
+		}
+		addKey(key, entry.Tags)
+
+		p.Sessions++
+		p.Minutes += minutes(entry.Duration)
+	}
+
+	// The parents come first in the keys, so their titles are known when their
+	// children are resolved
+	titles := make(map[projectKey]string)
+	owners := make(map[string]projectKey)
+	for _, key := range keys {
+		parent := ""
+		if key.parent != "" {
+			parent = titles[projectKey{key.parent, ""}]
+		}
+
+		title := key.title
+		existingParent, exists := knownProjects[title]
+		if key.parent != "" && (len(parentsOf[title]) > 1 || exists && existingParent != parent) {
+			title = key.parent + " / " + key.title
+			existingParent, exists = knownProjects[title]
+		}
+
+		owner, taken := owners[title]
+		switch {
+		case taken && owner != key:
+			p.Conflicts = append(p.Conflicts, fmt.Sprintf(
+				"Project %s is imported twice", title))
+		case exists && existingParent != parent:
+			where := "at the top level"
+			if existingParent != "" {
+				where = "under " + existingParent
+			}
+			p.Conflicts = append(p.Conflicts, fmt.Sprintf(
+				"Project %s already exists %s", title, where))
+		case !exists:
+			p.Projects = append(p.Projects, ProjectPlan{title, parent, keyTags[key]})
+		}
+		titles[key] = title
+		owners[title] = key
+	}
+
+	// Parents go first so that they exist when their children are created
+	sort.SliceStable(p.Projects, func(i, j int) bool {
+		return p.Projects[i].Parent == "" && p.Projects[j].Parent != ""
+	})
+	return p, titles, nil
+}
+
+// Import the entries into the database, or only report what would be created
+// if dryRun is set; nothing is imported if some of the entries conflict with
+// the existing projects and a failure part way leaves the database untouched
+func Import(db reef.Store, entries []Entry, dryRun bool) (Plan, error) {
+	p, titles, err := plan(db, entries)
+	if err != nil || dryRun {
+		return p, err
+	}
+	if len(p.Conflicts) != 0 {
+		return Plan{}, fmt.Errorf("Unable to import the entries: %s",
+			strings.Join(p.Conflicts, "; "))
+	}
+
+	err = db.Batch(func(store reef.Store) error {
+		return create(store, p, titles, entries)
+	})
+	if err != nil {
+		return Plan{}, err
+	}
+	return p, nil
+}
+
+func create(db reef.Store, p Plan, titles map[projectKey]string, entries []Entry) error {
+	for _, tag := range p.Tags {
+		if _, err := db.CreateTag(tag, defaultTagColor, 0); err != nil {
+			return err
+		}
+	}
+
+	tags, err := db.GetTagList()
+	if err != nil {
+		return err
+	}
+	tagIds := make(map[string]uint64)
+	for _, tag := range tags {
+		tagIds[tag.Name] = tag.Id
+	}
+
+	summaries, err := db.GetSummaryList()
+	if err != nil {
+		return err
+	}
+	projectIds := make(map[string]uint64)
+	for _, summary := range summaries {
+		projectIds[summary.Title] = summary.Id
+	}
+
+	for _, project := range p.Projects {
+		ids := []uint64{}
+		for _, tag := range project.Tags {
+			ids = append(ids, tagIds[tag])
+		}
+		id, err := db.CreateProject(project.Title, "", ids, projectIds[project.Parent])
+		if err != nil {
+			return err
+		}
+		projectIds[project.Title] = id
+	}
+
+	for _, entry := range entries {
+		duration := minutes(entry.Duration)
+		if duration == 0 {
+			continue
+		}
+		projectId := projectIds[titles[projectOf(entry)]]
+		err := db.AddSession(projectId, 0, duration, uint64(entry.Start.Unix()), entry.Note)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
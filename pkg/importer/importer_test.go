@@ -0,0 +1,249 @@
+//------------------------------------------------------------------------------
+// Author: Lukasz Janyst <lukasz@jany.st>
+// Date: 17.10.2026
+//
+// Licensed under the GPL 3 License, see the LICENSE file for details.
+//------------------------------------------------------------------------------
+
+package importer
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ljanyst/reef/pkg/reef"
+)
+
+func readTestData(t *testing.T, format, fileName string) []Entry {
+	f, err := os.Open(filepath.Join("testdata", fileName))
+	if err != nil {
+		t.Fatalf("Unable to open %s: %s", fileName, err)
+	}
+	defer f.Close()
+
+	entries, err := Read(format, f, time.UTC)
+	if err != nil {
+		t.Fatalf("Unable to read %s: %s", fileName, err)
+	}
+	return entries
+}
+
+func TestRead(t *testing.T) {
+	cases := []struct {
+		format   string
+		fileName string
+		expected []Entry
+	}{
+		{"toggl", "toggl.csv", []Entry{
+			{"Acme", "Website", []string{"design", "web"},
+				time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC), 90 * time.Minute, "Landing page"},
+			{"", "Admin", []string{},
+				time.Date(2026, 3, 3, 14, 0, 0, 0, time.UTC), 10 * time.Second, "Invoices, taxes"},
+		}},
+		{"clockify", "clockify.csv", []Entry{
+			{"Acme", "Website", []string{"design"},
+				time.Date(2026, 3, 2, 13, 15, 0, 0, time.UTC), 45 * time.Minute,
+				"Mockups: Landing page"},
+		}},
+		{"watson", "watson.json", []Entry{
+			{"", "reef", []string{"go", "backend"},
+				time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC), 2 * time.Hour, ""},
+			{"", "admin", []string{},
+				time.Date(2026, 3, 3, 9, 0, 0, 0, time.UTC), 30 * time.Minute, ""},
+		}},
+	}
+
+	for _, tc := range cases {
+		entries := readTestData(t, tc.format, tc.fileName)
+		if !reflect.DeepEqual(entries, tc.expected) {
+			t.Errorf("Wrong entries read from %s:\n%+v\n%+v", tc.fileName, entries,
+				tc.expected)
+		}
+	}
+}
+
+func TestImport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "reef-importer")
+	if err != nil {
+		t.Fatalf("Unable to create a temporary directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := reef.NewDatabase(&reef.BackendOpts{DatabaseDirectory: dir})
+	if err != nil {
+		t.Fatalf("Unable to create the database: %s", err)
+	}
+	if _, err := db.CreateProject("Admin", "", []uint64{}, 0); err != nil {
+		t.Fatalf("Unable to create a project: %s", err)
+	}
+
+	entries := readTestData(t, "toggl", "toggl.csv")
+	expected := Plan{
+		Tags: []string{"design", "web"},
+		Projects: []ProjectPlan{
+			{"Acme", "", []string{}},
+			{"Website", "Acme", []string{"design", "web"}},
+		},
+		Sessions:  1,
+		Minutes:   90,
+		Skipped:   1,
+		Conflicts: []string{},
+	}
+
+	plan, err := Import(db, entries, true)
+	if err != nil {
+		t.Fatalf("Dry run failed: %s", err)
+	}
+	if !reflect.DeepEqual(plan, expected) {
+		t.Errorf("Wrong dry run plan:\n%+v\n%+v", plan, expected)
+	}
+	if summaries, _ := db.GetSummaryList(); len(summaries) != 1 {
+		t.Errorf("Dry run should not create projects")
+	}
+
+	if plan, err = Import(db, entries, false); err != nil {
+		t.Fatalf("Import failed: %s", err)
+	}
+	if !reflect.DeepEqual(plan, expected) {
+		t.Errorf("Wrong import plan:\n%+v\n%+v", plan, expected)
+	}
+
+	summaries, err := db.GetSummaryList()
+	if err != nil || len(summaries) != 3 {
+		t.Fatalf("Import should create two projects: %v %s", summaries, err)
+	}
+	for _, summary := range summaries {
+		if summary.Title != "Website" {
+			continue
+		}
+		project, err := db.GetProjectById(summary.Id)
+		if err != nil {
+			t.Fatalf("Unable to get the imported project: %s", err)
+		}
+		if project.DurationTotal != 90 || len(project.Tags) != 2 || project.ParentId == 0 {
+			t.Errorf("Wrong imported project: %+v", project)
+		}
+	}
+}
+
+// Store failing the n-th session it is asked to add
+type failingStore struct {
+	reef.Store
+	sessions *int
+	failAt   int
+}
+
+func (s failingStore) AddSession(projectId, taskId, duration, date uint64, note string) error {
+	*s.sessions++
+	if *s.sessions == s.failAt {
+		return fmt.Errorf("Injected failure")
+	}
+	return s.Store.AddSession(projectId, taskId, duration, date, note)
+}
+
+func (s failingStore) Batch(fn func(store reef.Store) error) error {
+	return s.Store.Batch(func(tx reef.Store) error {
+		return fn(failingStore{tx, s.sessions, s.failAt})
+	})
+}
+
+func TestImportSharedProjectNames(t *testing.T) {
+	dir, err := ioutil.TempDir("", "reef-importer")
+	if err != nil {
+		t.Fatalf("Unable to create a temporary directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := reef.NewDatabase(&reef.BackendOpts{DatabaseDirectory: dir})
+	if err != nil {
+		t.Fatalf("Unable to create the database: %s", err)
+	}
+	if _, err := db.CreateProject("Website", "", []uint64{}, 0); err != nil {
+		t.Fatalf("Unable to create a project: %s", err)
+	}
+
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	entries := []Entry{
+		{"Acme", "Website", []string{"web"}, start, time.Hour, ""},
+		{"Beta", "Website", []string{}, start.Add(time.Hour), 2 * time.Hour, ""},
+		{"", "Website", []string{}, start.Add(3 * time.Hour), 3 * time.Hour, ""},
+	}
+	expected := Plan{
+		Tags: []string{"web"},
+		Projects: []ProjectPlan{
+			{"Acme", "", []string{}},
+			{"Beta", "", []string{}},
+			{"Acme / Website", "Acme", []string{"web"}},
+			{"Beta / Website", "Beta", []string{}},
+		},
+		Sessions:  3,
+		Minutes:   360,
+		Conflicts: []string{},
+	}
+
+	// A failure part way leaves nothing behind
+	sessions := 0
+	_, err = Import(failingStore{db, &sessions, 3}, entries, false)
+	if err == nil {
+		t.Fatalf("The import ignored the failure")
+	}
+	tags, _ := db.GetTagList()
+	summaries, _ := db.GetSummaryList()
+	if len(tags) != 0 || len(summaries) != 1 {
+		t.Fatalf("The failed import left changes behind: %v %v", tags, summaries)
+	}
+
+	plan, err := Import(db, entries, false)
+	if err != nil {
+		t.Fatalf("Import failed: %s", err)
+	}
+	if !reflect.DeepEqual(plan, expected) {
+		t.Errorf("Wrong import plan:\n%+v\n%+v", plan, expected)
+	}
+
+	summaries, err = db.GetSummaryList()
+	if err != nil {
+		t.Fatalf("Unable to list the projects: %s", err)
+	}
+	titles := make(map[uint64]string)
+	for _, summary := range summaries {
+		titles[summary.Id] = summary.Title
+	}
+	durations := map[string]uint64{"Website": 180, "Acme / Website": 60, "Beta / Website": 120}
+	parents := map[string]string{"Acme / Website": "Acme", "Beta / Website": "Beta"}
+	for _, summary := range summaries {
+		expectedDuration, ok := durations[summary.Title]
+		if !ok {
+			continue
+		}
+		project, err := db.GetProjectById(summary.Id)
+		if err != nil {
+			t.Fatalf("Unable to get project %s: %s", summary.Title, err)
+		}
+		if project.DurationTotal != expectedDuration ||
+			titles[project.ParentId] != parents[summary.Title] {
+			t.Errorf("Wrong imported project: %+v", project)
+		}
+	}
+
+	// The project names cannot be disambiguated at the top level
+	if _, err := db.MoveProject(1, 2); err != nil {
+		t.Fatalf("Unable to move the project: %s", err)
+	}
+	plan, err = Import(db, entries[2:], true)
+	if err != nil {
+		t.Fatalf("Dry run failed: %s", err)
+	}
+	conflicts := []string{"Project Website already exists under Acme"}
+	if !reflect.DeepEqual(plan.Conflicts, conflicts) {
+		t.Errorf("Wrong conflicts: %v", plan.Conflicts)
+	}
+	if _, err = Import(db, entries[2:], false); err == nil {
+		t.Errorf("The conflicting entries were imported")
+	}
+}
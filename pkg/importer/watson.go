@@ -0,0 +1,57 @@
+//------------------------------------------------------------------------------
+// Author: Lukasz Janyst <lukasz@jany.st>
+// Date: 17.10.2026
+//
+// Licensed under the GPL 3 License, see the LICENSE file for details.
+//------------------------------------------------------------------------------
+
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Read Watson's frames file; every frame is an array of the start and stop
+// timestamps, the project, the frame id, the tags and the update timestamp
+func ReadWatson(r io.Reader, loc *time.Location) ([]Entry, error) {
+	var frames [][]json.RawMessage
+	if err := json.NewDecoder(r).Decode(&frames); err != nil {
+		return nil, fmt.Errorf("Malformed frames: %s", err)
+	}
+
+	entries := []Entry{}
+	for i, frame := range frames {
+		if len(frame) < 3 {
+			return nil, fmt.Errorf("Frame %d is too short", i)
+		}
+
+		var start, stop int64
+		var entry Entry
+		if err := json.Unmarshal(frame[0], &start); err != nil {
+			return nil, fmt.Errorf("Frame %d has a malformed start: %s", i, err)
+		}
+		if err := json.Unmarshal(frame[1], &stop); err != nil {
+			return nil, fmt.Errorf("Frame %d has a malformed stop: %s", i, err)
+		}
+		if err := json.Unmarshal(frame[2], &entry.Project); err != nil {
+			return nil, fmt.Errorf("Frame %d has a malformed project: %s", i, err)
+		}
+		entry.Tags = []string{}
+		if len(frame) > 4 {
+			if err := json.Unmarshal(frame[4], &entry.Tags); err != nil {
+				return nil, fmt.Errorf("Frame %d has malformed tags: %s", i, err)
+			}
+		}
+		if stop < start {
+			return nil, fmt.Errorf("Frame %d stops before it starts", i)
+		}
+
+		entry.Start = time.Unix(start, 0).In(loc)
+		entry.Duration = time.Duration(stop-start) * time.Second
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
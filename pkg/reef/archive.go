@@ -0,0 +1,606 @@
+//------------------------------------------------------------------------------
+// Author: Lukasz Janyst <lukasz@jany.st>
+// Date: 17.10.2026
+//
+// Licensed under the GPL 3 License, see the LICENSE file for details.
+//------------------------------------------------------------------------------
+
+package reef
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Version of the archive document; bump it whenever the document changes in
+// a way that older versions of Reef cannot read
+const archiveVersion = 1
+
+type ArchiveTag struct {
+	Id       uint64 `json:"id"`
+	Name     string `json:"name"`
+	Color    string `json:"color"`
+	ParentId uint64 `json:"parentId"`
+}
+
+type ArchiveProject struct {
+	Id          uint64   `json:"id"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	ParentId    uint64   `json:"parentId"`
+	Status      string   `json:"status"`
+	Tags        []uint64 `json:"tags"`
+}
+
+type ArchiveMilestone struct {
+	Id         uint64 `json:"id"`
+	ProjectId  uint64 `json:"projectId"`
+	Name       string `json:"name"`
+	TargetDate uint64 `json:"targetDate"`
+}
+
+type ArchiveTask struct {
+	Id          uint64 `json:"id"`
+	ProjectId   uint64 `json:"projectId"`
+	ParentId    uint64 `json:"parentId"`
+	MilestoneId uint64 `json:"milestoneId"`
+	Done        bool   `json:"done"`
+	Position    uint64 `json:"position"`
+	TaskDetails
+	Tags      []uint64 `json:"tags"`
+	BlockedBy []uint64 `json:"blockedBy"`
+}
+
+type ArchiveSession struct {
+	Id        uint64 `json:"id"`
+	ProjectId uint64 `json:"projectId"`
+	TaskId    uint64 `json:"taskId"`
+	Date      uint64 `json:"date"`
+	Duration  uint64 `json:"duration"`
+	Note      string `json:"note"`
+}
+
+type ArchiveGoal struct {
+	ProjectId uint64 `json:"projectId"`
+	TagId     uint64 `json:"tagId"`
+	Period    string `json:"period"`
+	Minimum   uint64 `json:"minimum"`
+	Maximum   uint64 `json:"maximum"`
+}
+
+// A portable snapshot of the database; the ids are only meaningful within
+// the document and are remapped on import
+type Archive struct {
+	Version    int                `json:"version"`
+	Exported   uint64             `json:"exported"`
+	Tags       []ArchiveTag       `json:"tags"`
+	Projects   []ArchiveProject   `json:"projects"`
+	Milestones []ArchiveMilestone `json:"milestones"`
+	Tasks      []ArchiveTask      `json:"tasks"`
+	Sessions   []ArchiveSession   `json:"sessions"`
+	Goals      []ArchiveGoal      `json:"goals"`
+}
+
+// Number of the objects created by an import; the tags, projects and goals
+// that already existed are merged and not counted
+type ImportStats struct {
+	Tags       int `json:"tags"`
+	Projects   int `json:"projects"`
+	Milestones int `json:"milestones"`
+	Tasks      int `json:"tasks"`
+	Sessions   int `json:"sessions"`
+	Goals      int `json:"goals"`
+}
+
+// The archive is read in a single transaction so that it does not mix states
+// from before and after a concurrent change
+func (db *Database) GetArchive() (archive Archive, err error) {
+	err = db.readTransaction(func(tx *Database) error {
+		archive, err = tx.getArchive()
+		return err
+	})
+	return
+}
+
+func (db *Database) getArchive() (Archive, error) {
+	archive := Archive{
+		Version:    archiveVersion,
+		Exported:   uint64(time.Now().Unix()),
+		Tags:       []ArchiveTag{},
+		Projects:   []ArchiveProject{},
+		Milestones: []ArchiveMilestone{},
+		Tasks:      []ArchiveTask{},
+		Sessions:   []ArchiveSession{},
+		Goals:      []ArchiveGoal{},
+	}
+
+	err := db.forEachRow("SELECT id, name, color, parentId FROM tags ORDER BY id;",
+		func(rows *sql.Rows) error {
+			var tag ArchiveTag
+			err := rows.Scan(&tag.Id, &tag.Name, &tag.Color, &tag.ParentId)
+			archive.Tags = append(archive.Tags, tag)
+			return err
+		})
+	if err != nil {
+		return Archive{}, fmt.Errorf("Unable to archive tags: %s", err)
+	}
+
+	query := "SELECT id, title, description, parentId, status, " +
+		"(SELECT " + db.db.dialect.groupConcat("tagId", ",") +
+		" FROM projectTags WHERE projectId = projects.id) " +
+		"FROM projects ORDER BY id;"
+	err = db.forEachRow(query, func(rows *sql.Rows) error {
+		var project ArchiveProject
+		var tags sql.NullString
+		err := rows.Scan(&project.Id, &project.Title, &project.Description,
+			&project.ParentId, &project.Status, &tags)
+		if err != nil {
+			return err
+		}
+		if project.Tags, err = parseIdList(tags); err != nil {
+			return err
+		}
+		archive.Projects = append(archive.Projects, project)
+		return nil
+	})
+	if err != nil {
+		return Archive{}, fmt.Errorf("Unable to archive projects: %s", err)
+	}
+
+	query = "SELECT id, projectId, name, targetDate FROM milestones ORDER BY id;"
+	err = db.forEachRow(query, func(rows *sql.Rows) error {
+		var milestone ArchiveMilestone
+		err := rows.Scan(&milestone.Id, &milestone.ProjectId, &milestone.Name,
+			&milestone.TargetDate)
+		archive.Milestones = append(archive.Milestones, milestone)
+		return err
+	})
+	if err != nil {
+		return Archive{}, fmt.Errorf("Unable to archive milestones: %s", err)
+	}
+
+	// Older databases kept the tasks and the sessions of the deleted projects;
+	// they are left out, so that the archive can be imported back
+	query = "SELECT " + db.taskColumns() + " FROM tasks " +
+		"WHERE projectId IN (SELECT id FROM projects) ORDER BY id;"
+	tasks, err := db.queryTasks(query)
+	if err != nil {
+		return Archive{}, fmt.Errorf("Unable to archive tasks: %s", err)
+	}
+	exported := make(map[uint64]bool)
+	for _, task := range tasks {
+		exported[task.Id] = true
+	}
+	for _, task := range tasks {
+		blockedBy := []uint64{}
+		for _, blockerId := range task.BlockedBy {
+			if exported[blockerId] {
+				blockedBy = append(blockedBy, blockerId)
+			}
+		}
+		archive.Tasks = append(archive.Tasks, ArchiveTask{
+			Id:          task.Id,
+			ProjectId:   task.ProjectId,
+			ParentId:    task.ParentId,
+			MilestoneId: task.MilestoneId,
+			Done:        task.Done,
+			Position:    task.Position,
+			TaskDetails: TaskDetails{
+				Title:              task.Title,
+				Description:        task.Description,
+				Priority:           uint64(task.Priority),
+				StartDate:          task.StartDate,
+				DueDate:            task.DueDate,
+				Recurrence:         task.Recurrence,
+				RecurrenceInterval: task.RecurrenceInterval,
+				Estimate:           task.Estimate,
+			},
+			Tags:      task.Tags,
+			BlockedBy: blockedBy,
+		})
+	}
+
+	query = "SELECT id, projectId, taskId, timestamp, duration, note FROM sessions " +
+		"WHERE projectId IN (SELECT id FROM projects) ORDER BY id;"
+	err = db.forEachRow(query, func(rows *sql.Rows) error {
+		var session ArchiveSession
+		var dt sessionTime
+		err := rows.Scan(&session.Id, &session.ProjectId, &session.TaskId, &dt,
+			&session.Duration, &session.Note)
+		session.Date = uint64(dt.Unix())
+		archive.Sessions = append(archive.Sessions, session)
+		return err
+	})
+	if err != nil {
+		return Archive{}, fmt.Errorf("Unable to archive sessions: %s", err)
+	}
+
+	query = "SELECT projectId, tagId, period, minimum, maximum FROM goals ORDER BY id;"
+	err = db.forEachRow(query, func(rows *sql.Rows) error {
+		var goal ArchiveGoal
+		err := rows.Scan(&goal.ProjectId, &goal.TagId, &goal.Period, &goal.Minimum,
+			&goal.Maximum)
+		archive.Goals = append(archive.Goals, goal)
+		return err
+	})
+	if err != nil {
+		return Archive{}, fmt.Errorf("Unable to archive goals: %s", err)
+	}
+
+	return archive, nil
+}
+
+func (db *Database) forEachRow(query string, fn func(*sql.Rows) error) error {
+	rows, err := db.db.Query(query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		if err := fn(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (db *Database) ExportArchive(w io.Writer) error {
+	archive, err := db.GetArchive()
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(archive)
+}
+
+func ReadArchive(r io.Reader) (Archive, error) {
+	var archive Archive
+	if err := json.NewDecoder(r).Decode(&archive); err != nil {
+		return Archive{}, fmt.Errorf("Malformed archive: %s", err)
+	}
+	if archive.Version < 1 || archive.Version > archiveVersion {
+		return Archive{}, fmt.Errorf("Unsupported archive version: %d", archive.Version)
+	}
+	return archive, nil
+}
+
+// Look up a row with the query and insert it if it's not there; returns the
+// id of the row and whether it was created
+func findOrInsert(tx *dbConn, find string, findArgs []interface{}, insert string,
+	insertArgs ...interface{}) (uint64, bool, error) {
+	var id uint64
+	err := tx.QueryRow(find, findArgs...).Scan(&id)
+	if err == nil {
+		return id, false, nil
+	}
+	if err != sql.ErrNoRows {
+		return 0, false, err
+	}
+
+	id, err = tx.dialect.insert(tx, insert, insertArgs...)
+	return id, err == nil, err
+}
+
+// Call fn for the items in an order where the parents come before their
+// children; fails if some parents cannot be resolved
+func forEachParentFirst(n int, parentOf func(int) uint64, idOf func(int) uint64,
+	fn func(int) error) error {
+	done := make(map[uint64]bool)
+	pending := make([]int, n)
+	for i := range pending {
+		pending[i] = i
+	}
+
+	for len(pending) != 0 {
+		remaining := []int{}
+		for _, i := range pending {
+			if parent := parentOf(i); parent != 0 && !done[parent] {
+				remaining = append(remaining, i)
+				continue
+			}
+			if err := fn(i); err != nil {
+				return err
+			}
+			done[idOf(i)] = true
+		}
+		if len(remaining) == len(pending) {
+			return fmt.Errorf("Unknown parent %d of item %d", parentOf(remaining[0]),
+				idOf(remaining[0]))
+		}
+		pending = remaining
+	}
+	return nil
+}
+
+func mapId(idMap map[uint64]uint64, id uint64, kind string) (uint64, error) {
+	if id == 0 {
+		return 0, nil
+	}
+	newId, ok := idMap[id]
+	if !ok {
+		return 0, fmt.Errorf("Unknown %s id: %d", kind, id)
+	}
+	return newId, nil
+}
+
+// Merge the archive into the database; tags and projects are matched by their
+// unique names and goals by all their attributes, while milestones, tasks and
+// sessions are always created since nothing identifies them across databases
+func (db *Database) ImportArchive(archive Archive) (stats ImportStats, err error) {
+	err = db.transaction(func(tx *Database) error {
+		stats, err = tx.importArchive(archive)
+		return err
+	})
+	return
+}
+
+func (db *Database) importArchive(archive Archive) (ImportStats, error) {
+	var stats ImportStats
+	tx := db.db
+
+	tagMap := make(map[uint64]uint64)
+	err := forEachParentFirst(len(archive.Tags),
+		func(i int) uint64 { return archive.Tags[i].ParentId },
+		func(i int) uint64 { return archive.Tags[i].Id },
+		func(i int) error {
+			tag := archive.Tags[i]
+			parentId, _ := mapId(tagMap, tag.ParentId, "tag")
+			id, created, err := findOrInsert(tx,
+				"SELECT id FROM tags WHERE name = ?;", []interface{}{tag.Name},
+				"INSERT INTO tags (name, color, parentId) VALUES (?, ?, ?);",
+				tag.Name, tag.Color, parentId)
+			if err != nil {
+				return fmt.Errorf("Unable to import tag %s: %s", tag.Name, err)
+			}
+			if created {
+				stats.Tags++
+			}
+			tagMap[tag.Id] = id
+			return nil
+		})
+	if err != nil {
+		return ImportStats{}, err
+	}
+
+	projectMap := make(map[uint64]uint64)
+	now := time.Now().Unix()
+	err = forEachParentFirst(len(archive.Projects),
+		func(i int) uint64 { return archive.Projects[i].ParentId },
+		func(i int) uint64 { return archive.Projects[i].Id },
+		func(i int) error {
+			project := archive.Projects[i]
+			if project.Status == "" {
+				project.Status = ProjectActive
+			}
+			parentId, _ := mapId(projectMap, project.ParentId, "project")
+			id, created, err := findOrInsert(tx,
+				"SELECT id FROM projects WHERE title = ?;", []interface{}{project.Title},
+				"INSERT INTO projects (title, description, parentId, status) "+
+					"VALUES (?, ?, ?, ?);",
+				project.Title, project.Description, parentId, project.Status)
+			if err != nil {
+				return fmt.Errorf("Unable to import project %s: %s", project.Title, err)
+			}
+			projectMap[project.Id] = id
+			if !created {
+				return nil
+			}
+			stats.Projects++
+
+			query := "INSERT INTO projectStatusHistory (projectId, status, timestamp) " +
+				"VALUES (?, ?, ?);"
+			if _, err := tx.Exec(query, id, project.Status, now); err != nil {
+				return fmt.Errorf("Unable to record the status of %s: %s", project.Title, err)
+			}
+
+			for _, tagId := range project.Tags {
+				newTagId, err := mapId(tagMap, tagId, "tag")
+				if err != nil {
+					return err
+				}
+				query := "INSERT INTO projectTags (projectId, tagId) " +
+					"VALUES (?, ?) ON CONFLICT DO NOTHING;"
+				if _, err := tx.Exec(query, id, newTagId); err != nil {
+					return fmt.Errorf("Unable to tag project %s: %s", project.Title, err)
+				}
+			}
+			return nil
+		})
+	if err != nil {
+		return ImportStats{}, err
+	}
+
+	milestoneMap := make(map[uint64]uint64)
+	for _, milestone := range archive.Milestones {
+		projectId, err := mapId(projectMap, milestone.ProjectId, "project")
+		if err != nil {
+			return ImportStats{}, err
+		}
+		id, err := tx.dialect.insert(tx,
+			"INSERT INTO milestones (projectId, name, targetDate) VALUES (?, ?, ?);",
+			projectId, milestone.Name, milestone.TargetDate)
+		if err != nil {
+			return ImportStats{}, fmt.Errorf("Unable to import milestone %s: %s",
+				milestone.Name, err)
+		}
+		stats.Milestones++
+		milestoneMap[milestone.Id] = id
+	}
+
+	// New tasks go to the end of their projects in their original order
+	tasks := append([]ArchiveTask{}, archive.Tasks...)
+	sort.SliceStable(tasks, func(i, j int) bool { return tasks[i].Position < tasks[j].Position })
+
+	taskMap := make(map[uint64]uint64)
+	err = forEachParentFirst(len(tasks),
+		func(i int) uint64 { return tasks[i].ParentId },
+		func(i int) uint64 { return tasks[i].Id },
+		func(i int) error {
+			task := tasks[i]
+			projectId, err := mapId(projectMap, task.ProjectId, "project")
+			if err != nil {
+				return err
+			}
+			milestoneId, err := mapId(milestoneMap, task.MilestoneId, "milestone")
+			if err != nil {
+				return err
+			}
+			parentId, _ := mapId(taskMap, task.ParentId, "task")
+			id, err := tx.dialect.insert(tx,
+				"INSERT INTO tasks (projectId, parentId, done, priority, title, "+
+					"description, startDate, dueDate, recurrence, recurrenceInterval, "+
+					"estimate, milestoneId, position) "+
+					"SELECT ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, COALESCE(MAX(position), 0) + 1 "+
+					"FROM tasks WHERE projectId = ?;",
+				projectId, parentId, task.Done, task.Priority, task.Title, task.Description,
+				task.StartDate, task.DueDate, task.Recurrence, task.RecurrenceInterval,
+				task.Estimate, milestoneId, projectId)
+			if err != nil {
+				return fmt.Errorf("Unable to import task %s: %s", task.Title, err)
+			}
+			taskMap[task.Id] = id
+			stats.Tasks++
+			return nil
+		})
+	if err != nil {
+		return ImportStats{}, err
+	}
+
+	for _, task := range tasks {
+		for _, tagId := range task.Tags {
+			newTagId, err := mapId(tagMap, tagId, "tag")
+			if err != nil {
+				return ImportStats{}, err
+			}
+			query := "INSERT INTO taskTags (taskId, tagId) " +
+				"VALUES (?, ?) ON CONFLICT DO NOTHING;"
+			if _, err := tx.Exec(query, taskMap[task.Id], newTagId); err != nil {
+				return ImportStats{}, fmt.Errorf("Unable to tag task %s: %s", task.Title, err)
+			}
+		}
+		for _, blockerId := range task.BlockedBy {
+			newBlockerId, err := mapId(taskMap, blockerId, "task")
+			if err != nil {
+				return ImportStats{}, err
+			}
+			query := "INSERT INTO taskDependencies (taskId, blockerId) " +
+				"VALUES (?, ?) ON CONFLICT DO NOTHING;"
+			if _, err := tx.Exec(query, taskMap[task.Id], newBlockerId); err != nil {
+				return ImportStats{}, fmt.Errorf("Unable to link task %s: %s", task.Title, err)
+			}
+		}
+	}
+
+	for _, session := range archive.Sessions {
+		projectId, err := mapId(projectMap, session.ProjectId, "project")
+		if err != nil {
+			return ImportStats{}, err
+		}
+		taskId, err := mapId(taskMap, session.TaskId, "task")
+		if err != nil {
+			return ImportStats{}, err
+		}
+		query := "INSERT INTO sessions (projectId, taskId, timestamp, duration, note) " +
+			"VALUES (?, ?, ?, ?, ?);"
+		_, err = tx.Exec(query, projectId, taskId, session.Date, session.Duration, session.Note)
+		if err != nil {
+			return ImportStats{}, fmt.Errorf("Unable to import session %d: %s", session.Id, err)
+		}
+		stats.Sessions++
+	}
+
+	for _, goal := range archive.Goals {
+		projectId, err := mapId(projectMap, goal.ProjectId, "project")
+		if err != nil {
+			return ImportStats{}, err
+		}
+		tagId, err := mapId(tagMap, goal.TagId, "tag")
+		if err != nil {
+			return ImportStats{}, err
+		}
+		_, created, err := findOrInsert(tx,
+			"SELECT id FROM goals WHERE projectId = ? AND tagId = ? AND period = ? AND "+
+				"minimum = ? AND maximum = ?;",
+			[]interface{}{projectId, tagId, goal.Period, goal.Minimum, goal.Maximum},
+			"INSERT INTO goals (projectId, tagId, period, minimum, maximum) "+
+				"VALUES (?, ?, ?, ?, ?);",
+			projectId, tagId, goal.Period, goal.Minimum, goal.Maximum)
+		if err != nil {
+			return ImportStats{}, fmt.Errorf("Unable to import goal: %s", err)
+		}
+		if created {
+			stats.Goals++
+		}
+	}
+
+	return stats, nil
+}
+
+type ArchiveExportHandler struct {
+	db *Database
+}
+
+func (handler ArchiveExportHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", `attachment; filename="reef.json"`)
+	if err := handler.db.ExportArchive(w); err != nil {
+		log.Errorf("Unable to export the database: %s", err)
+	}
+}
+
+func NewArchiveExportHandler(db *Database) ArchiveExportHandler {
+	var h ArchiveExportHandler
+	h.db = db
+	return h
+}
+
+// The largest archive accepted for an import
+const maxArchiveSize = 256 << 20
+
+// Imports run on the controller so that they don't race with the websocket
+// clients, which get the refreshed state afterwards
+type ArchiveImportHandler struct {
+	db         *Database
+	controller *Controller
+}
+
+func (handler ArchiveImportHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Archives must be posted", http.StatusMethodNotAllowed)
+		return
+	}
+
+	archive, err := ReadArchive(http.MaxBytesReader(w, r.Body, maxArchiveSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var stats ImportStats
+	err = handler.controller.Execute(func() error {
+		stats, err = handler.db.ImportArchive(archive)
+		return err
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+func NewArchiveImportHandler(db *Database, controller *Controller) ArchiveImportHandler {
+	var h ArchiveImportHandler
+	h.db = db
+	h.controller = controller
+	return h
+}
@@ -0,0 +1,137 @@
+//------------------------------------------------------------------------------
+// Author: Lukasz Janyst <lukasz@jany.st>
+// Date: 17.10.2026
+//
+// Licensed under the GPL 3 License, see the LICENSE file for details.
+//------------------------------------------------------------------------------
+
+package reef
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func newTempDatabase(t *testing.T) (*Database, func()) {
+	dir := tempDir(t)
+	db, err := NewDatabase(&BackendOpts{DatabaseDirectory: dir})
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatalf("Unable to create the database: %s", err)
+	}
+	return db, func() {
+		db.db.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func getArchive(t *testing.T, db *Database) Archive {
+	archive, err := db.GetArchive()
+	if err != nil {
+		t.Fatalf("Unable to export the database: %s", err)
+	}
+	archive.Exported = 0
+	return archive
+}
+
+// Recurring instances share their titles and sessions may be identical, so
+// nothing but the tags and projects can be merged by content
+func TestArchiveRoundTrip(t *testing.T) {
+	db, cleanup := newTempDatabase(t)
+	defer cleanup()
+
+	now := uint64(time.Now().Unix())
+	steps := []func() error{
+		func() error { _, err := db.CreateTag("work", "#ff0000", 0); return err },
+		func() error { _, err := db.CreateProject("Alpha", "", []uint64{1}, 0); return err },
+		func() error { return db.CreateMilestone(1, "M1", now) },
+		func() error { return db.CreateMilestone(1, "M1", now) },
+		func() error {
+			return db.AddTask(1, 0, TaskDetails{Title: "Standup", Recurrence: "daily",
+				RecurrenceInterval: 1, DueDate: now})
+		},
+		func() error { _, err := db.ToggleTask(1, false); return err },
+		func() error { return db.AddSession(1, 1, 15, now-3600, "") },
+		func() error { return db.AddSession(1, 1, 15, now-3600, "") },
+		func() error { return db.AddSession(1, 2, 15, now-7200, "") },
+		func() error { return db.AddSession(1, 0, 30, now-7200, "") },
+	}
+	for i, step := range steps {
+		if err := step(); err != nil {
+			t.Fatalf("Unable to populate the database, step %d: %s", i, err)
+		}
+	}
+
+	archive := getArchive(t, db)
+	if len(archive.Tasks) != 2 || len(archive.Sessions) != 4 || len(archive.Milestones) != 2 {
+		t.Fatalf("Wrong archive: %d tasks, %d sessions, %d milestones", len(archive.Tasks),
+			len(archive.Sessions), len(archive.Milestones))
+	}
+
+	restored, cleanupRestored := newTempDatabase(t)
+	defer cleanupRestored()
+	stats, err := restored.ImportArchive(archive)
+	if err != nil {
+		t.Fatalf("Unable to import the archive: %s", err)
+	}
+	expectedStats := ImportStats{Tags: 1, Projects: 1, Milestones: 2, Tasks: 2, Sessions: 4}
+	if stats != expectedStats {
+		t.Errorf("Wrong import stats: %v", stats)
+	}
+	if actual := getArchive(t, restored); !reflect.DeepEqual(actual, archive) {
+		t.Errorf("Wrong archive after the round trip:\n%v\nExpected:\n%v", actual, archive)
+	}
+
+	// Merging again reuses the tag and the project and adds everything else
+	stats, err = restored.ImportArchive(archive)
+	if err != nil {
+		t.Fatalf("Unable to import the archive again: %s", err)
+	}
+	expectedStats = ImportStats{Milestones: 2, Tasks: 2, Sessions: 4}
+	if stats != expectedStats {
+		t.Errorf("Wrong import stats of the merge: %v", stats)
+	}
+	merged := getArchive(t, restored)
+	if len(merged.Tags) != 1 || len(merged.Projects) != 1 || len(merged.Tasks) != 4 ||
+		len(merged.Sessions) != 8 {
+		t.Errorf("Wrong merged archive: %v", merged)
+	}
+
+	// The deleted projects leave nothing behind in the archive, even when an
+	// older version kept their tasks and sessions in the database
+	pruned, cleanupPruned := newTempDatabase(t)
+	defer cleanupPruned()
+	steps = []func() error{
+		func() error { _, err := pruned.CreateProject("Alpha", "", []uint64{}, 0); return err },
+		func() error { _, err := pruned.CreateProject("Beta", "", []uint64{}, 0); return err },
+		func() error { _, err := pruned.CreateProject("Gamma", "", []uint64{}, 0); return err },
+		func() error { return pruned.AddTask(1, 0, TaskDetails{Title: "A"}) },
+		func() error { return pruned.AddTask(2, 0, TaskDetails{Title: "B"}) },
+		func() error { return pruned.AddTask(3, 0, TaskDetails{Title: "G"}) },
+		func() error { _, err := pruned.LinkTasks(2, 1); return err },
+		func() error { _, err := pruned.LinkTasks(2, 3); return err },
+		func() error { return pruned.AddSession(1, 1, 15, now-3600, "") },
+		func() error { return pruned.AddSession(2, 2, 15, now-3600, "") },
+		func() error { return pruned.AddSession(3, 3, 15, now-3600, "") },
+		func() error { _, err := pruned.DeleteProject(1); return err },
+		func() error { _, err := pruned.db.Exec("DELETE FROM projects WHERE id = 3;"); return err },
+	}
+	for i, step := range steps {
+		if err := step(); err != nil {
+			t.Fatalf("Unable to populate the database, step %d: %s", i, err)
+		}
+	}
+
+	archive = getArchive(t, pruned)
+	if len(archive.Projects) != 1 || len(archive.Tasks) != 1 || len(archive.Sessions) != 1 ||
+		len(archive.Tasks[0].BlockedBy) != 0 {
+		t.Fatalf("Wrong archive after deleting projects: %v", archive)
+	}
+	restored, cleanupRestored = newTempDatabase(t)
+	defer cleanupRestored()
+	if _, err := restored.ImportArchive(archive); err != nil {
+		t.Fatalf("Unable to import the archive after deleting projects: %s", err)
+	}
+}
@@ -0,0 +1,205 @@
+//------------------------------------------------------------------------------
+// Author: Lukasz Janyst <lukasz@jany.st>
+// Date: 17.10.2026
+//
+// Licensed under the GPL 3 License, see the LICENSE file for details.
+//------------------------------------------------------------------------------
+
+package reef
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const icsTimeFormat = "20060102T150405Z"
+
+// Writes iCalendar content lines; the lines end with CRLF and are folded so
+// that none of them is longer than 75 octets
+type icsWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+var icsEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`,
+	"\n", `\n`)
+
+func (ics *icsWriter) line(name, value string) {
+	if ics.err != nil {
+		return
+	}
+
+	content := name + ":" + value
+	for len(content) > 75 {
+		// Don't split UTF-8 sequences
+		cut := 75
+		for cut > 0 && content[cut]&0xc0 == 0x80 {
+			cut--
+		}
+		if _, ics.err = ics.w.WriteString(content[:cut] + "\r\n"); ics.err != nil {
+			return
+		}
+		content = " " + content[cut:]
+	}
+	_, ics.err = ics.w.WriteString(content + "\r\n")
+}
+
+func (ics *icsWriter) text(name, value string) {
+	ics.line(name, icsEscaper.Replace(value))
+}
+
+func (ics *icsWriter) time(name string, timestamp uint64) {
+	ics.line(name, time.Unix(int64(timestamp), 0).UTC().Format(icsTimeFormat))
+}
+
+func (ics *icsWriter) begin(name string) {
+	ics.line("BEGIN", "VCALENDAR")
+	ics.line("VERSION", "2.0")
+	ics.line("PRODID", "-//Reef//Reef Calendar//EN")
+	ics.line("CALSCALE", "GREGORIAN")
+	ics.text("X-WR-CALNAME", name)
+}
+
+func (ics *icsWriter) end() error {
+	ics.line("END", "VCALENDAR")
+	if ics.err != nil {
+		return ics.err
+	}
+	return ics.w.Flush()
+}
+
+// iCalendar priorities go from 1 (highest) to 9 (lowest)
+var icsPriorities = map[uint8]string{0: "9", 1: "5", 2: "1"}
+
+// Write the tasks that have a start or a due date as VTODO entries
+func (db *Database) WriteTaskCalendar(w io.Writer) error {
+	return db.writeTaskCalendar(w, uint64(time.Now().Unix()))
+}
+
+func (db *Database) writeTaskCalendar(w io.Writer, stamp uint64) error {
+	summaries, err := db.GetSummaryList()
+	if err != nil {
+		return err
+	}
+	titles := make(map[uint64]string)
+	for _, summary := range summaries {
+		titles[summary.Id] = summary.Title
+	}
+
+	query := "SELECT " + db.taskColumns() + " FROM tasks " +
+		"WHERE startDate != 0 OR dueDate != 0 ORDER BY id;"
+	tasks, err := db.queryTasks(query)
+	if err != nil {
+		return fmt.Errorf("Unable to query tasks: %s", err)
+	}
+
+	ics := icsWriter{w: bufio.NewWriter(w)}
+	ics.begin("Reef tasks")
+	for _, task := range tasks {
+		ics.line("BEGIN", "VTODO")
+		ics.line("UID", fmt.Sprintf("task-%d@reef", task.Id))
+		ics.time("DTSTAMP", stamp)
+		ics.text("SUMMARY", task.Title)
+		ics.text("CATEGORIES", titles[task.ProjectId])
+		if task.Description != "" {
+			ics.text("DESCRIPTION", task.Description)
+		}
+		if task.StartDate != 0 {
+			ics.time("DTSTART", task.StartDate)
+		}
+		if task.DueDate != 0 {
+			ics.time("DUE", task.DueDate)
+		}
+		ics.line("PRIORITY", icsPriorities[task.Priority])
+		if task.Done {
+			ics.line("STATUS", "COMPLETED")
+		} else {
+			ics.line("STATUS", "NEEDS-ACTION")
+		}
+		ics.line("END", "VTODO")
+	}
+	return ics.end()
+}
+
+// Write the sessions matching the filter as VEVENT entries; like the other
+// exports, they are read in full before anything is sent to the client
+func (db *Database) WriteSessionCalendar(w io.Writer, filter SessionFilter) error {
+	return db.writeSessionCalendar(w, filter, uint64(time.Now().Unix()))
+}
+
+func (db *Database) writeSessionCalendar(w io.Writer, filter SessionFilter,
+	stamp uint64) error {
+	ics := icsWriter{w: bufio.NewWriter(w)}
+	ics.begin("Reef sessions")
+	err := db.forEachSession(filter, func(s ExportedSession) error {
+		summary := s.Project
+		if s.Task != "" {
+			summary += ": " + s.Task
+		}
+		ics.line("BEGIN", "VEVENT")
+		ics.line("UID", fmt.Sprintf("session-%d@reef", s.Id))
+		ics.time("DTSTAMP", stamp)
+		ics.time("DTSTART", s.Timestamp)
+		ics.time("DTEND", s.Timestamp+s.Duration*60)
+		ics.text("SUMMARY", summary)
+		if s.Note != "" {
+			ics.text("DESCRIPTION", s.Note)
+		}
+		if len(s.Tags) != 0 {
+			escaped := []string{}
+			for _, tag := range s.Tags {
+				escaped = append(escaped, icsEscaper.Replace(tag))
+			}
+			ics.line("CATEGORIES", strings.Join(escaped, ","))
+		}
+		ics.line("TRANSP", "TRANSPARENT")
+		ics.line("END", "VEVENT")
+		return ics.err
+	})
+	if err != nil {
+		return err
+	}
+	return ics.end()
+}
+
+type CalendarHandler struct {
+	db   *Database
+	feed string
+}
+
+func (handler CalendarHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if handler.feed == "tasks" {
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		if err := handler.db.WriteTaskCalendar(w); err != nil {
+			log.Errorf("Unable to write the task calendar: %s", err)
+		}
+		return
+	}
+
+	query := r.URL.Query()
+	filter, err := handler.db.NewSessionFilter(query.Get("from"), query.Get("to"),
+		query["tag"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	if err := handler.db.WriteSessionCalendar(w, filter); err != nil {
+		log.Errorf("Unable to write the session calendar: %s", err)
+	}
+}
+
+// The feed is either tasks or sessions
+func NewCalendarHandler(db *Database, feed string) CalendarHandler {
+	var h CalendarHandler
+	h.db = db
+	h.feed = feed
+	return h
+}
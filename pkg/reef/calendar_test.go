@@ -0,0 +1,112 @@
+//------------------------------------------------------------------------------
+// Author: Lukasz Janyst <lukasz@jany.st>
+// Date: 17.10.2026
+//
+// Licensed under the GPL 3 License, see the LICENSE file for details.
+//------------------------------------------------------------------------------
+
+package reef
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+const calendarStamp = 1790000000
+
+func TestCalendar(t *testing.T) {
+	db, cleanup := newTempDatabase(t)
+	defer cleanup()
+
+	steps := []func() error{
+		func() error { _, err := db.CreateTag("clients, a;b", "#ff0000", 0); return err },
+		func() error { _, err := db.CreateTag("ops", "#00ff00", 0); return err },
+		func() error { _, err := db.CreateProject("Alpha", "", []uint64{1, 2}, 0); return err },
+		func() error {
+			return db.AddTask(1, 0, TaskDetails{Title: "Write the report; draft, then send",
+				Description: "First line\nSecond line with a \\ backslash", Priority: 2,
+				StartDate: calendarStamp - 86400, DueDate: calendarStamp})
+		},
+		func() error {
+			return db.AddTask(1, 0, TaskDetails{Title: strings.Repeat("Zażółć gęślą jaźń ", 5),
+				DueDate: calendarStamp + 86400})
+		},
+		func() error { return db.AddTask(1, 0, TaskDetails{Title: "No dates"}) },
+		func() error { _, err := db.ToggleTask(2, false); return err },
+		func() error { return db.AddSession(1, 1, 90, calendarStamp-7200, "Notes, with; marks") },
+		func() error { return db.AddSession(1, 0, 30, calendarStamp-3600, "") },
+	}
+	for i, step := range steps {
+		if err := step(); err != nil {
+			t.Fatalf("Unable to populate the database, step %d: %s", i, err)
+		}
+	}
+
+	filter, err := db.NewSessionFilter("", "", []string{})
+	if err != nil {
+		t.Fatalf("Unable to create the filter: %s", err)
+	}
+
+	feeds := map[string]func(*bytes.Buffer) error{
+		"calendar-tasks": func(b *bytes.Buffer) error {
+			return db.writeTaskCalendar(b, calendarStamp)
+		},
+		"calendar-sessions": func(b *bytes.Buffer) error {
+			return db.writeSessionCalendar(b, filter, calendarStamp)
+		},
+	}
+	for name, feed := range feeds {
+		var b bytes.Buffer
+		if err := feed(&b); err != nil {
+			t.Fatalf("Unable to write %s: %s", name, err)
+		}
+
+		for _, line := range strings.SplitAfter(b.String(), "\r\n") {
+			if len(line) > 77 || !utf8.ValidString(line) {
+				t.Errorf("Malformed line in %s: %q", name, line)
+			}
+		}
+
+		goldenFile := filepath.Join("testdata", name+".golden")
+		if *update {
+			ioutil.WriteFile(goldenFile, b.Bytes(), 0644)
+		}
+		expected, _ := ioutil.ReadFile(goldenFile)
+		if !bytes.Equal(b.Bytes(), expected) {
+			t.Errorf("Actual and golden %s don't match\n%s\n%s", name, b.String(),
+				string(expected))
+		}
+	}
+}
+
+// The feeds are polled regularly, so serving them must not block the writers
+func TestCalendarDoesNotBlockWriters(t *testing.T) {
+	db, cleanup := newTempDatabase(t)
+	defer cleanup()
+
+	if _, err := db.CreateProject("Alpha", "", []uint64{}, 0); err != nil {
+		t.Fatalf("Unable to create the project: %s", err)
+	}
+	// Enough sessions to overflow the buffer of the writer
+	for i := uint64(0); i < 100; i++ {
+		if err := db.AddSession(1, 0, 30, calendarStamp-3600*(i+1), ""); err != nil {
+			t.Fatalf("Unable to add a session: %s", err)
+		}
+	}
+
+	filter, err := db.NewSessionFilter("", "", []string{})
+	if err != nil {
+		t.Fatalf("Unable to create the filter: %s", err)
+	}
+	client := &writingClient{t: t, db: db}
+	if err := db.WriteSessionCalendar(client, filter); err != nil {
+		t.Fatalf("Unable to write the calendar: %s", err)
+	}
+	if client.writes < 2 {
+		t.Errorf("The calendar was not streamed: %d writes", client.writes)
+	}
+}
@@ -26,22 +26,43 @@ type ctrl struct {
 	Sync         chan bool
 }
 
+type execRequest struct {
+	Call   func() error
+	Result chan error
+}
+
 type requestWrapper struct {
 	ClientId uint64
 	Request  Request
 }
 
 type Controller struct {
-	db           *Database
+	db           Store
 	lastLinkId   uint64
 	broadcastMap map[uint64]chan<- Response
 	requestChan  chan requestWrapper
 	controlChan  chan ctrl
+	executeChan  chan execRequest
 	callMap      map[string]func(*Controller, *Request) (interface{}, error)
 }
 
+// Broadcast the state of the tags and of all their ancestors, since the time
+// spent rolls up the tag tree
 func (c *Controller) notifyTags(tagIds []uint64) {
+	allTagIds := []uint64{}
 	for _, tagId := range tagIds {
+		ancestors, err := c.db.GetTagAncestorIds(tagId)
+		if err != nil {
+			log.Errorf("Cannot find ancestors of tag %d: %s", tagId, err)
+		}
+		for _, id := range append([]uint64{tagId}, ancestors...) {
+			if !inList(id, allTagIds) {
+				allTagIds = append(allTagIds, id)
+			}
+		}
+	}
+
+	for _, tagId := range allTagIds {
 		if tagInfo, err := c.db.GetTagById(tagId); err != nil {
 			log.Errorf("Cannot find tag for id: %v. The database is inconsistent.", tagId)
 			continue
@@ -51,7 +72,23 @@ func (c *Controller) notifyTags(tagIds []uint64) {
 	}
 }
 
+// Broadcast the state of the project and of all its ancestors, since the
+// time spent and the completeness roll up the project tree
 func (c *Controller) notifyProject(id uint64) {
+	c.notifySingleProject(id)
+
+	ancestors, err := c.db.GetProjectAncestorIds(id)
+	if err != nil {
+		log.Errorf("Cannot find ancestors of project %d: %s", id, err)
+		return
+	}
+
+	for _, ancestorId := range ancestors {
+		c.notifySingleProject(ancestorId)
+	}
+}
+
+func (c *Controller) notifySingleProject(id uint64) {
 	summary, err := c.db.GetSummaryById(id)
 	if err != nil {
 		log.Errorf("Cannot find project for id: %v. The database is inconsistent.", id)
@@ -73,6 +110,16 @@ func (c *Controller) notifyProjects(projectIds []uint64) {
 	}
 }
 
+// Notify the projects whose tasks depend on tasks in the project that has
+// just been notified
+func (c *Controller) notifyDependentProjects(projectId uint64, dependents []uint64) {
+	for _, id := range dependents {
+		if id != projectId {
+			c.notifyProject(id)
+		}
+	}
+}
+
 func (c *Controller) notifyProjectAndTags(projectId uint64) {
 	var tagIds []uint64
 	var err error
@@ -85,12 +132,34 @@ func (c *Controller) notifyProjectAndTags(projectId uint64) {
 	c.notifyProject(projectId)
 }
 
+func (c *Controller) notifyGoalOwner(projectId, tagId uint64) {
+	if projectId != 0 {
+		c.notifySingleProject(projectId)
+	}
+	if tagId != 0 {
+		if tagInfo, err := c.db.GetTagById(tagId); err != nil {
+			log.Errorf("Cannot find tag for id: %v. The database is inconsistent.", tagId)
+		} else {
+			c.broadcastMessage("TAG_UPDATE", tagInfo)
+		}
+	}
+}
+
+func (c *Controller) notifyTimer(projectId uint64) {
+	timer, err := c.db.GetTimer(projectId)
+	if err != nil {
+		log.Errorf("Cannot find timer for project id: %v. The database is inconsistent.", projectId)
+	} else {
+		c.broadcastMessage("TIMER_UPDATE", timer)
+	}
+}
+
 func (c *Controller) createCallMap() {
 	c.callMap = make(map[string]func(*Controller, *Request) (interface{}, error))
 
 	c.callMap["TAG_NEW"] = func(c *Controller, req *Request) (interface{}, error) {
 		p := req.TagNewParams
-		id, err := c.db.CreateTag(p.Name, p.Color)
+		id, err := c.db.CreateTag(p.Name, p.Color, p.ParentId)
 		if err != nil {
 			return nil, err
 		}
@@ -106,12 +175,41 @@ func (c *Controller) createCallMap() {
 
 	c.callMap["TAG_DELETE"] = func(c *Controller, req *Request) (interface{}, error) {
 		id := req.TagDeleteParams
+		tag, err := c.db.GetTagById(id)
+		if err != nil {
+			return nil, err
+		}
+
+		children, err := c.db.GetTagChildIds(id)
+		if err != nil {
+			return nil, err
+		}
+
 		projectIds, err := c.db.DeleteTag(id)
 		if err != nil {
 			return nil, err
 		}
 		c.notifyProjects(projectIds)
 		c.broadcastMessage("TAG_DELETE", id)
+		if tag.ParentId != 0 {
+			children = append(children, tag.ParentId)
+		}
+		c.notifyTags(children)
+		return nil, nil
+	}
+
+	c.callMap["TAG_MOVE"] = func(c *Controller, req *Request) (interface{}, error) {
+		p := req.TagMoveParams
+		oldParentId, err := c.db.MoveTag(p.Id, p.ParentId)
+		if err != nil {
+			return nil, err
+		}
+
+		tagIds := []uint64{p.Id}
+		if oldParentId != 0 {
+			tagIds = append(tagIds, oldParentId)
+		}
+		c.notifyTags(tagIds)
 		return nil, nil
 	}
 
@@ -131,7 +229,7 @@ func (c *Controller) createCallMap() {
 
 	c.callMap["PROJECT_NEW"] = func(c *Controller, req *Request) (interface{}, error) {
 		p := req.ProjectNewParams
-		id, err := c.db.CreateProject(p.Name, p.Description, p.Tags)
+		id, err := c.db.CreateProject(p.Name, p.Description, p.Tags, p.ParentId)
 		if err != nil {
 			return nil, err
 		}
@@ -142,9 +240,38 @@ func (c *Controller) createCallMap() {
 		}
 		c.broadcastMessage("SUMMARY_UPDATE", summary)
 		c.notifyTags(p.Tags)
+		if p.ParentId != 0 {
+			c.notifyProject(p.ParentId)
+		}
 		return nil, nil
 	}
 
+	c.callMap["PROJECT_MOVE"] = func(c *Controller, req *Request) (interface{}, error) {
+		p := req.ProjectMoveParams
+		oldParentId, err := c.db.MoveProject(p.Id, p.ParentId)
+		if err != nil {
+			return nil, err
+		}
+		c.notifyProject(p.Id)
+		if oldParentId != 0 {
+			c.notifyProject(oldParentId)
+		}
+		return nil, nil
+	}
+
+	c.callMap["PROJECT_STATUS"] = func(c *Controller, req *Request) (interface{}, error) {
+		p := req.ProjectStatusParams
+		if err := c.db.SetProjectStatus(p.Id, p.Status); err != nil {
+			return nil, err
+		}
+		c.notifySingleProject(p.Id)
+		return nil, nil
+	}
+
+	c.callMap["SUMMARY_TREE"] = func(c *Controller, req *Request) (interface{}, error) {
+		return c.db.GetSummaryTree()
+	}
+
 	c.callMap["PROJECT_GET"] = func(c *Controller, req *Request) (interface{}, error) {
 		id := req.ProjectGetParams
 		project, err := c.db.GetProjectById(id)
@@ -156,12 +283,26 @@ func (c *Controller) createCallMap() {
 
 	c.callMap["PROJECT_DELETE"] = func(c *Controller, req *Request) (interface{}, error) {
 		id := req.ProjectDeleteParams
+		summary, err := c.db.GetSummaryById(id)
+		if err != nil {
+			return nil, err
+		}
+
+		children, err := c.db.GetProjectChildIds(id)
+		if err != nil {
+			return nil, err
+		}
+
 		tags, err := c.db.DeleteProject(id)
 		if err != nil {
 			return nil, err
 		}
 		c.broadcastMessage("PROJECT_DELETE", id)
 		c.notifyTags(tags)
+		c.notifyProjects(children)
+		if summary.ParentId != 0 {
+			c.notifyProject(summary.ParentId)
+		}
 		return nil, nil
 	}
 
@@ -178,8 +319,7 @@ func (c *Controller) createCallMap() {
 
 	c.callMap["TASK_NEW"] = func(c *Controller, req *Request) (interface{}, error) {
 		p := req.TaskNewParams
-		err := c.db.AddTask(p.ProjectId, p.Title, p.Description, p.Priority)
-		if err != nil {
+		if err := c.db.AddTask(p.ProjectId, p.ParentId, p.TaskDetails); err != nil {
 			return nil, err
 		}
 		c.notifyProject(p.ProjectId)
@@ -187,16 +327,89 @@ func (c *Controller) createCallMap() {
 	}
 
 	c.callMap["TASK_DELETE"] = func(c *Controller, req *Request) (interface{}, error) {
-		projectId, err := c.db.DeleteTask(req.TaskDeleteParams)
+		id := req.TaskDeleteParams
+		dependents, err := c.db.GetDependentProjectIds(id)
+		if err != nil {
+			return nil, err
+		}
+
+		tags, err := c.db.GetSubtaskTagIds(id)
+		if err != nil {
+			return nil, err
+		}
+
+		projectId, err := c.db.DeleteTask(id)
 		if err != nil {
 			return nil, err
 		}
 		c.notifyProject(projectId)
+		c.notifyDependentProjects(projectId, dependents)
+		c.notifyTags(tags)
 		return nil, nil
 	}
 
 	c.callMap["TASK_TOGGLE"] = func(c *Controller, req *Request) (interface{}, error) {
-		projectId, err := c.db.ToggleTask(req.TaskToggleParams)
+		p := req.TaskToggleParams
+		projectId, err := c.db.ToggleTask(p.TaskId, p.Cascade)
+		if err != nil {
+			return nil, err
+		}
+		c.notifyProject(projectId)
+
+		dependents, err := c.db.GetDependentProjectIds(p.TaskId)
+		if err != nil {
+			log.Errorf("Cannot find tasks blocked by task %d: %s", p.TaskId, err)
+		} else {
+			c.notifyDependentProjects(projectId, dependents)
+		}
+		return nil, nil
+	}
+
+	c.callMap["TASK_REORDER"] = func(c *Controller, req *Request) (interface{}, error) {
+		p := req.TaskReorderParams
+		projectId, err := c.db.ReorderTask(p.TaskId, p.TargetId, p.After)
+		if err != nil {
+			return nil, err
+		}
+		c.notifyProject(projectId)
+		return nil, nil
+	}
+
+	c.callMap["TASK_TAG_ADD"] = func(c *Controller, req *Request) (interface{}, error) {
+		p := req.TaskTagAddParams
+		projectId, err := c.db.AddTaskTag(p.TaskId, p.TagId)
+		if err != nil {
+			return nil, err
+		}
+		c.notifyProject(projectId)
+		c.notifyTags([]uint64{p.TagId})
+		return nil, nil
+	}
+
+	c.callMap["TASK_TAG_REMOVE"] = func(c *Controller, req *Request) (interface{}, error) {
+		p := req.TaskTagRemoveParams
+		projectId, err := c.db.RemoveTaskTag(p.TaskId, p.TagId)
+		if err != nil {
+			return nil, err
+		}
+		c.notifyProject(projectId)
+		c.notifyTags([]uint64{p.TagId})
+		return nil, nil
+	}
+
+	c.callMap["TASK_LINK"] = func(c *Controller, req *Request) (interface{}, error) {
+		p := req.TaskLinkParams
+		projectId, err := c.db.LinkTasks(p.TaskId, p.BlockerId)
+		if err != nil {
+			return nil, err
+		}
+		c.notifyProject(projectId)
+		return nil, nil
+	}
+
+	c.callMap["TASK_UNLINK"] = func(c *Controller, req *Request) (interface{}, error) {
+		p := req.TaskUnlinkParams
+		projectId, err := c.db.UnlinkTasks(p.TaskId, p.BlockerId)
 		if err != nil {
 			return nil, err
 		}
@@ -206,7 +419,97 @@ func (c *Controller) createCallMap() {
 
 	c.callMap["TASK_EDIT"] = func(c *Controller, req *Request) (interface{}, error) {
 		p := req.TaskEditParams
-		projectId, err := c.db.EditTask(p.TaskId, p.Title, p.Description, p.Priority)
+		projectId, err := c.db.EditTask(p.TaskId, p.TaskDetails)
+		if err != nil {
+			return nil, err
+		}
+		c.notifyProject(projectId)
+		return nil, nil
+	}
+
+	c.callMap["TASK_LIST_DUE"] = func(c *Controller, req *Request) (interface{}, error) {
+		days := req.TaskListDueParams
+		if days == 0 {
+			days = 7
+		}
+		return c.db.GetDueTasks(days)
+	}
+
+	c.callMap["REPORT_ESTIMATES"] = func(c *Controller, req *Request) (interface{}, error) {
+		return c.db.GetEstimateReport(req.ReportEstimatesParams)
+	}
+
+	c.callMap["REPORT_RANGE"] = func(c *Controller, req *Request) (interface{}, error) {
+		p := req.ReportRangeParams
+		return c.db.GetRangeReport(p.From, p.To, p.Period, p.Offset)
+	}
+
+	c.callMap["REPORT_QUERY"] = func(c *Controller, req *Request) (interface{}, error) {
+		p := req.ReportQueryParams
+		return c.db.QueryReport(p.From, p.To, p.Period, p.Offset, p.ProjectIds, p.TagIds,
+			p.GroupBy)
+	}
+
+	c.callMap["MILESTONE_NEW"] = func(c *Controller, req *Request) (interface{}, error) {
+		p := req.MilestoneNewParams
+		if err := c.db.CreateMilestone(p.ProjectId, p.Name, p.TargetDate); err != nil {
+			return nil, err
+		}
+		c.notifyProject(p.ProjectId)
+		return nil, nil
+	}
+
+	c.callMap["MILESTONE_EDIT"] = func(c *Controller, req *Request) (interface{}, error) {
+		p := req.MilestoneEditParams
+		projectId, err := c.db.EditMilestone(p.Id, p.Name, p.TargetDate)
+		if err != nil {
+			return nil, err
+		}
+		c.notifyProject(projectId)
+		return nil, nil
+	}
+
+	c.callMap["MILESTONE_DELETE"] = func(c *Controller, req *Request) (interface{}, error) {
+		projectId, err := c.db.DeleteMilestone(req.MilestoneDeleteParams)
+		if err != nil {
+			return nil, err
+		}
+		c.notifyProject(projectId)
+		return nil, nil
+	}
+
+	c.callMap["GOAL_NEW"] = func(c *Controller, req *Request) (interface{}, error) {
+		p := req.GoalNewParams
+		err := c.db.CreateGoal(p.ProjectId, p.TagId, p.Period, p.Minimum, p.Maximum)
+		if err != nil {
+			return nil, err
+		}
+		c.notifyGoalOwner(p.ProjectId, p.TagId)
+		return nil, nil
+	}
+
+	c.callMap["GOAL_EDIT"] = func(c *Controller, req *Request) (interface{}, error) {
+		p := req.GoalEditParams
+		projectId, tagId, err := c.db.EditGoal(p.Id, p.Period, p.Minimum, p.Maximum)
+		if err != nil {
+			return nil, err
+		}
+		c.notifyGoalOwner(projectId, tagId)
+		return nil, nil
+	}
+
+	c.callMap["GOAL_DELETE"] = func(c *Controller, req *Request) (interface{}, error) {
+		projectId, tagId, err := c.db.DeleteGoal(req.GoalDeleteParams)
+		if err != nil {
+			return nil, err
+		}
+		c.notifyGoalOwner(projectId, tagId)
+		return nil, nil
+	}
+
+	c.callMap["MILESTONE_ASSIGN"] = func(c *Controller, req *Request) (interface{}, error) {
+		p := req.MilestoneAssignParams
+		projectId, err := c.db.AssignMilestone(p.TaskId, p.MilestoneId)
 		if err != nil {
 			return nil, err
 		}
@@ -216,7 +519,7 @@ func (c *Controller) createCallMap() {
 
 	c.callMap["SESSION_NEW"] = func(c *Controller, req *Request) (interface{}, error) {
 		p := req.SessionNewParams
-		if err := c.db.AddSession(p.ProjectId, p.Duration, p.Date); err != nil {
+		if err := c.db.AddSession(p.ProjectId, p.TaskId, p.Duration, p.Date, p.Note); err != nil {
 			return nil, err
 		}
 		c.notifyProjectAndTags(p.ProjectId)
@@ -232,6 +535,51 @@ func (c *Controller) createCallMap() {
 		c.notifyProjectAndTags(projectId)
 		return nil, nil
 	}
+
+	c.callMap["SESSION_EDIT"] = func(c *Controller, req *Request) (interface{}, error) {
+		p := req.SessionEditParams
+		projectId, err := c.db.EditSession(p.Id, p.TaskId, p.Duration, p.Date, p.Note)
+		if err != nil {
+			return nil, err
+		}
+		c.notifyProjectAndTags(projectId)
+		return nil, nil
+	}
+
+	c.callMap["SESSION_START"] = func(c *Controller, req *Request) (interface{}, error) {
+		projectId := req.SessionStartParams
+		if _, err := c.db.GetSummaryById(projectId); err != nil {
+			return nil, fmt.Errorf("Unable to find project %d: %s", projectId, err)
+		}
+
+		if err := c.db.StartTimer(projectId); err != nil {
+			return nil, err
+		}
+		c.notifyTimer(projectId)
+		return nil, nil
+	}
+
+	c.callMap["SESSION_PAUSE"] = func(c *Controller, req *Request) (interface{}, error) {
+		projectId := req.SessionPauseParams
+		if err := c.db.PauseTimer(projectId); err != nil {
+			return nil, err
+		}
+		c.notifyTimer(projectId)
+		return nil, nil
+	}
+
+	c.callMap["SESSION_STOP"] = func(c *Controller, req *Request) (interface{}, error) {
+		projectId := req.SessionStopParams
+		duration, err := c.db.StopTimer(projectId)
+		if err != nil {
+			return nil, err
+		}
+		c.broadcastMessage("TIMER_DELETE", projectId)
+		if duration != 0 {
+			c.notifyProjectAndTags(projectId)
+		}
+		return nil, nil
+	}
 }
 
 func (c *Controller) GetLink() *Link {
@@ -257,6 +605,15 @@ func (c *Controller) GetLink() *Link {
 	return link
 }
 
+// Run the function on the controller goroutine and send the refreshed state to
+// all the clients if it succeeds; meant for bulk changes made outside of the
+// websocket actions
+func (c *Controller) Execute(call func() error) error {
+	result := make(chan error)
+	c.executeChan <- execRequest{call, result}
+	return <-result
+}
+
 func (c *Controller) writeErrorToClient(clientId uint64, msgId string, err error) {
 	if channel, ok := c.broadcastMap[clientId]; ok {
 		channel <- Response{"ACTION_EXECUTED", err.Error(), msgId, "ERROR"}
@@ -287,6 +644,12 @@ func (c *Controller) sendInitialData(channel chan<- Response) {
 	} else {
 		channel <- Response{"SUMMARY_LIST", summaries, "", ""}
 	}
+
+	if timers, err := c.db.GetTimerList(); err != nil {
+		log.Errorf("Unable to get the timer list: %s", err)
+	} else {
+		channel <- Response{"TIMER_LIST", timers, "", ""}
+	}
 }
 
 func (c *Controller) handleRequests() {
@@ -315,17 +678,26 @@ func (c *Controller) handleRequests() {
 				delete(c.broadcastMap, ctrl.Id)
 				ctrl.Sync <- true
 			}
+		case exec := <-c.executeChan:
+			err := exec.Call()
+			if err == nil {
+				for _, channel := range c.broadcastMap {
+					c.sendInitialData(channel)
+				}
+			}
+			exec.Result <- err
 		}
 	}
 }
 
-func NewController(db *Database) *Controller {
+func NewController(db Store) *Controller {
 	c := new(Controller)
 	c.lastLinkId = 0
 	c.db = db
 	c.broadcastMap = make(map[uint64]chan<- Response, 250)
 	c.requestChan = make(chan requestWrapper, 100)
 	c.controlChan = make(chan ctrl)
+	c.executeChan = make(chan execRequest)
 	c.createCallMap()
 	go c.handleRequests()
 	return c
@@ -21,10 +21,11 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
-const currentVersion = 3
-
 type Database struct {
-	db *sql.DB
+	db         *dbConn
+	periods    Periods
+	dir        string // Directory of the SQLite database file
+	migrations *migrationRegistry
 }
 
 func (db *Database) readMetadata() (md map[string]string, err error) {
@@ -55,33 +56,133 @@ type CommandEntry struct {
 	ErrorMsg string
 }
 
-func (db *Database) initializeNew() error {
-	initializationQueries := []CommandEntry{
+// SQLite cannot drop columns, so reverting the migrations that add them
+// rebuilds the tables; these are the columns of the extended tables along with
+// the versions that introduced them
+type versionedColumn struct {
+	version    uint64
+	definition string
+}
+
+var sqliteTableColumns = map[string][]versionedColumn{
+	"tags": {
+		{3, "id INTEGER PRIMARY KEY AUTOINCREMENT"},
+		{3, "name STRING UNIQUE NOT NULL"},
+		{3, "color STRING NOT NULL"},
+		{13, "parentId INTEGER NOT NULL DEFAULT 0"},
+	},
+	"projects": {
+		{1, "id INTEGER PRIMARY KEY AUTOINCREMENT"},
+		{1, "title STRING UNIQUE NOT NULL"},
+		{1, "description STRING NOT NULL"},
+		{14, "parentId INTEGER NOT NULL DEFAULT 0"},
+		{16, `status STRING NOT NULL DEFAULT "active"`},
+	},
+	"tasks": {
+		{2, "id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT"},
+		{2, "projectId INTEGER NOT NULL"},
+		{2, "done BOOLEAN NOT NULL"},
+		{2, "priority INTEGER NOT NULL"},
+		{2, "title STRING NOT NULL"},
+		{2, "description STRING NOT NULL"},
+		{5, "startDate INTEGER NOT NULL DEFAULT 0"},
+		{5, "dueDate INTEGER NOT NULL DEFAULT 0"},
+		{6, "parentId INTEGER NOT NULL DEFAULT 0"},
+		{8, `recurrence STRING NOT NULL DEFAULT ""`},
+		{8, "recurrenceInterval INTEGER NOT NULL DEFAULT 1"},
+		{9, "estimate INTEGER NOT NULL DEFAULT 0"},
+		{11, "position INTEGER NOT NULL DEFAULT 0"},
+		{15, "milestoneId INTEGER NOT NULL DEFAULT 0"},
+		{2, "FOREIGN KEY(projectId) REFERENCES projects(id)"},
+	},
+	"sessions": {
+		{1, "id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT"},
+		{1, "projectId INTEGER NOT NULL"},
+		{1, "timestamp DATETIME NOT NULL"},
+		{1, "duration INTEGER NOT NULL"},
+		{9, "taskId INTEGER NOT NULL DEFAULT 0"},
+		{10, `note STRING NOT NULL DEFAULT ""`},
+		{1, "FOREIGN KEY(projectId) REFERENCES projects(id)"},
+	},
+}
+
+// Rebuild the table with the columns it had at the given version; the new
+// table is renamed rather than the old one because SQLite would point the
+// foreign keys of the other tables to the renamed table
+func rebuildTable(table string, version uint64) []CommandEntry {
+	definitions := []string{}
+	columns := []string{}
+	for _, column := range sqliteTableColumns[table] {
+		if column.version > version {
+			continue
+		}
+		definitions = append(definitions, column.definition)
+		if !strings.HasPrefix(column.definition, "FOREIGN KEY") {
+			columns = append(columns, strings.Fields(column.definition)[0])
+		}
+	}
+
+	newTable := "_" + table + "_new"
+	columnList := strings.Join(columns, ", ")
+	return []CommandEntry{
+		{
+			"CREATE TABLE " + newTable + " (" + strings.Join(definitions, ", ") + ");",
+			"Unable to create the new " + table + " table",
+		},
+		{
+			"INSERT INTO " + newTable + " (" + columnList + ") " +
+				"SELECT " + columnList + " FROM " + table + ";",
+			"Unable to copy the rows to the new " + table + " table",
+		},
+		{
+			"DROP TABLE " + table + ";",
+			"Unable to drop the old " + table + " table",
+		},
 		{
-			fmt.Sprintf(`INSERT INTO metadata (key, value) VALUES ("version", "%d");`, currentVersion),
-			"Unable to set the database version",
+			"ALTER TABLE " + newTable + " RENAME TO " + table + ";",
+			"Unable to rename " + newTable + " to " + table,
 		},
+	}
+}
+
+var sqliteMigrations = &migrationRegistry{
+	schema: []CommandEntry{
 		{
 			"CREATE TABLE tags (" +
 				"id INTEGER PRIMARY KEY AUTOINCREMENT, " +
 				"name STRING UNIQUE NOT NULL, " +
-				"color STRING NOT NULL);",
+				"color STRING NOT NULL, " +
+				"parentId INTEGER NOT NULL DEFAULT 0);",
 			"Unable to create the tags table",
 		},
-		{
-			"INSERT INTO tags (name, color) " +
-				"VALUES" +
-				`("Limbo", "#778899"),` +
-				`("Archived", "#3cb371");`,
-			"Unable to create the built-in tags",
-		},
 		{
 			"CREATE TABLE projects (" +
 				"id INTEGER PRIMARY KEY AUTOINCREMENT, " +
 				"title STRING UNIQUE NOT NULL, " +
-				"description STRING NOT NULL);",
+				"description STRING NOT NULL, " +
+				"parentId INTEGER NOT NULL DEFAULT 0, " +
+				`status STRING NOT NULL DEFAULT "active");`,
 			"Unable to create the projects table",
 		},
+		{
+			"CREATE TABLE projectStatusHistory (" +
+				"id INTEGER PRIMARY KEY AUTOINCREMENT, " +
+				"projectId INTEGER NOT NULL, " +
+				"status STRING NOT NULL, " +
+				"timestamp INTEGER NOT NULL, " +
+				"FOREIGN KEY(projectId) REFERENCES projects(id));",
+			"Unable to create the project status history table",
+		},
+		{
+			"CREATE TABLE goals (" +
+				"id INTEGER PRIMARY KEY AUTOINCREMENT, " +
+				"projectId INTEGER NOT NULL DEFAULT 0, " +
+				"tagId INTEGER NOT NULL DEFAULT 0, " +
+				"period STRING NOT NULL, " +
+				"minimum INTEGER NOT NULL DEFAULT 0, " +
+				"maximum INTEGER NOT NULL DEFAULT 0);",
+			"Unable to create the goals table",
+		},
 		{
 			"CREATE TABLE projectTags (" +
 				"projectId INTEGER NOT NULL, " +
@@ -99,6 +200,14 @@ func (db *Database) initializeNew() error {
 				"priority INTEGER NOT NULL, " +
 				"title STRING NOT NULL," +
 				"description STRING NOT NULL," +
+				"startDate INTEGER NOT NULL DEFAULT 0, " +
+				"dueDate INTEGER NOT NULL DEFAULT 0, " +
+				"parentId INTEGER NOT NULL DEFAULT 0, " +
+				`recurrence STRING NOT NULL DEFAULT "", ` +
+				"recurrenceInterval INTEGER NOT NULL DEFAULT 1, " +
+				"estimate INTEGER NOT NULL DEFAULT 0, " +
+				"position INTEGER NOT NULL DEFAULT 0, " +
+				"milestoneId INTEGER NOT NULL DEFAULT 0, " +
 				"FOREIGN KEY(projectId) REFERENCES projects(id));",
 			"Unable to create the tasks table",
 		},
@@ -108,110 +217,489 @@ func (db *Database) initializeNew() error {
 				"projectId INTEGER NOT NULL, " +
 				"timestamp DATETIME NOT NULL, " +
 				"duration INTEGER NOT NULL," +
+				"taskId INTEGER NOT NULL DEFAULT 0, " +
+				`note STRING NOT NULL DEFAULT "", ` +
 				"FOREIGN KEY(projectId) REFERENCES projects(id));",
 			"Unable to create the sessions table",
 		},
-	}
-
-	return executeQueries(db.db, initializationQueries)
-}
-
-func upgradeFrom1To2(db *sql.DB) error {
-	log.Info("Upgrading database from version 1 to version 2")
-	queries := []CommandEntry{
 		{
-			"ALTER TABLE tasks RENAME TO _tasks_old;",
-			"Unable to rename tasks to _tasks_old",
+			"CREATE TABLE timers (" +
+				"projectId INTEGER NOT NULL PRIMARY KEY, " +
+				"started INTEGER NOT NULL, " +
+				"resumed INTEGER NOT NULL, " +
+				"elapsed INTEGER NOT NULL, " +
+				"running BOOLEAN NOT NULL, " +
+				"FOREIGN KEY(projectId) REFERENCES projects(id));",
+			"Unable to create the timers table",
 		},
 		{
-			"CREATE TABLE tasks (" +
-				"id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT, " +
+			"CREATE TABLE taskDependencies (" +
+				"taskId INTEGER NOT NULL, " +
+				"blockerId INTEGER NOT NULL, " +
+				"CONSTRAINT PK_Pair PRIMARY KEY (taskId, blockerId)" +
+				"FOREIGN KEY(taskId) REFERENCES tasks(id)," +
+				"FOREIGN KEY(blockerId) REFERENCES tasks(id));",
+			"Unable to create the task dependency table",
+		},
+		{
+			"CREATE TABLE taskTags (" +
+				"taskId INTEGER NOT NULL, " +
+				"tagId INTEGER NOT NULL, " +
+				"CONSTRAINT PK_Pair PRIMARY KEY (taskId, tagId)" +
+				"FOREIGN KEY(taskId) REFERENCES tasks(id)," +
+				"FOREIGN KEY(tagId) REFERENCES tags(id));",
+			"Unable to create the task-tag table",
+		},
+		{
+			"CREATE TABLE milestones (" +
+				"id INTEGER PRIMARY KEY AUTOINCREMENT, " +
 				"projectId INTEGER NOT NULL, " +
-				"done BOOLEAN NOT NULL, " +
-				"priority INTEGER NOT NULL, " +
-				"title STRING NOT NULL," +
-				"description STRING NOT NULL," +
+				"name STRING NOT NULL, " +
+				"targetDate INTEGER NOT NULL, " +
 				"FOREIGN KEY(projectId) REFERENCES projects(id));",
-			"Unable to create the new tasks table",
+			"Unable to create the milestones table",
+		},
+	},
+	migrations: []Migration{
+
+		// The migrations of the early versions renumber the tags and merge the
+		// task columns in ways that cannot be undone
+		{
+			Version: 2,
+			Name:    "Split the task titles from the descriptions",
+			Up: []CommandEntry{
+				{
+					"ALTER TABLE tasks RENAME TO _tasks_old;",
+					"Unable to rename tasks to _tasks_old",
+				},
+				{
+					"CREATE TABLE tasks (" +
+						"id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT, " +
+						"projectId INTEGER NOT NULL, " +
+						"done BOOLEAN NOT NULL, " +
+						"priority INTEGER NOT NULL, " +
+						"title STRING NOT NULL," +
+						"description STRING NOT NULL," +
+						"FOREIGN KEY(projectId) REFERENCES projects(id));",
+					"Unable to create the new tasks table",
+				},
+				{
+					"INSERT INTO tasks (id, projectId, done, priority, title, description)" +
+						`SELECT id, projectId, done, 1, description, ""` +
+						"FROM _tasks_old;",
+					"Unable to copy the rows to the new tasks table",
+				},
+				{
+					"DROP TABLE _tasks_old;",
+					"Unable to drop the old tasks table",
+				},
+			},
 		},
+
 		{
-			"INSERT INTO tasks (id, projectId, done, priority, title, description)" +
-				`SELECT id, projectId, done, 1, description, ""` +
-				"FROM _tasks_old;",
-			"Unable to copy the rows to the new tasks table",
+			Version: 3,
+			Name:    "Add the Limbo and Archived tags",
+			Up: []CommandEntry{
+				{
+					"ALTER TABLE tags RENAME TO _tags_old;",
+					"Unable to rename tags to _tags_old",
+				},
+				{
+					"CREATE TABLE tags (" +
+						"id INTEGER PRIMARY KEY AUTOINCREMENT, " +
+						"name STRING UNIQUE NOT NULL, " +
+						"color STRING NOT NULL);",
+					"Unable to create the tags table",
+				},
+				{
+					"INSERT INTO tags (name, color) " +
+						"VALUES" +
+						`("Limbo", "#778899");`,
+					"Unable to create the Limbo tag",
+				},
+				{
+					"INSERT INTO tags (id, name, color) " +
+						`SELECT id+1, name, color ` +
+						"FROM _tags_old;",
+					"Unable to copy the rows to the new tags table",
+				},
+				{
+					"DROP TABLE _tags_old;",
+					"Unable to drop the old tags table",
+				},
+				{
+					`UPDATE tags SET color = "#3cb371" WHERE id = 2`,
+					"Cannot update the color of the Archived tag",
+				},
+				{
+					"ALTER TABLE projectTags RENAME TO _projectTags_old;",
+					"Unable to rename projectTags to _projectTags_old",
+				},
+				{
+					"CREATE TABLE projectTags (" +
+						"projectId INTEGER NOT NULL, " +
+						"tagId INTEGER NOT NULL, " +
+						"CONSTRAINT PK_Pair PRIMARY KEY (projectId, tagId)" +
+						"FOREIGN KEY(projectId) REFERENCES projects(id)," +
+						"FOREIGN KEY(tagId) REFERENCES tags(id));",
+					"Unable to create the project-tag table.",
+				},
+				{
+					"INSERT INTO projectTags (projectId, tagId) " +
+						`SELECT projectId, tagId+1 ` +
+						"FROM _projectTags_old;",
+					"Unable to copy the rows to the new tasks table",
+				},
+				{
+					"DROP TABLE _projectTags_old;",
+					"Unable to drop the old projectTags table",
+				},
+			},
 		},
+
 		{
-			"DROP TABLE _tasks_old;",
-			"Unable to drop the old tasks table",
+			Version: 4,
+			Name:    "Add the timers",
+			Up: []CommandEntry{
+				{
+					"CREATE TABLE timers (" +
+						"projectId INTEGER NOT NULL PRIMARY KEY, " +
+						"started INTEGER NOT NULL, " +
+						"resumed INTEGER NOT NULL, " +
+						"elapsed INTEGER NOT NULL, " +
+						"running BOOLEAN NOT NULL, " +
+						"FOREIGN KEY(projectId) REFERENCES projects(id));",
+					"Unable to create the timers table",
+				},
+			},
+			Down: []CommandEntry{
+				{
+					"DROP TABLE timers;",
+					"Unable to drop the timers table",
+				},
+			},
 		},
-	}
 
-	return executeQueries(db, queries)
-}
+		{
+			Version: 5,
+			Name:    "Add the task start and due dates",
+			Up: []CommandEntry{
+				{
+					"ALTER TABLE tasks ADD COLUMN startDate INTEGER NOT NULL DEFAULT 0;",
+					"Unable to add the start date column to the tasks table",
+				},
+				{
+					"ALTER TABLE tasks ADD COLUMN dueDate INTEGER NOT NULL DEFAULT 0;",
+					"Unable to add the due date column to the tasks table",
+				},
+			},
+			Down: rebuildTable("tasks", 4),
+		},
 
-func upgradeFrom2To3(db *sql.DB) error {
-	log.Info("Upgrading database from version 2 to version 3")
-	queries := []CommandEntry{
 		{
-			"ALTER TABLE tags RENAME TO _tags_old;",
-			"Unable to rename tags to _tags_old",
+			Version: 6,
+			Name:    "Add the subtasks",
+			Up: []CommandEntry{
+				{
+					"ALTER TABLE tasks ADD COLUMN parentId INTEGER NOT NULL DEFAULT 0;",
+					"Unable to add the parent id column to the tasks table",
+				},
+			},
+			Down: rebuildTable("tasks", 5),
 		},
+
 		{
-			"CREATE TABLE tags (" +
-				"id INTEGER PRIMARY KEY AUTOINCREMENT, " +
-				"name STRING UNIQUE NOT NULL, " +
-				"color STRING NOT NULL);",
-			"Unable to create the tags table",
+			Version: 7,
+			Name:    "Add the task dependencies",
+			Up: []CommandEntry{
+				{
+					"CREATE TABLE taskDependencies (" +
+						"taskId INTEGER NOT NULL, " +
+						"blockerId INTEGER NOT NULL, " +
+						"CONSTRAINT PK_Pair PRIMARY KEY (taskId, blockerId)" +
+						"FOREIGN KEY(taskId) REFERENCES tasks(id)," +
+						"FOREIGN KEY(blockerId) REFERENCES tasks(id));",
+					"Unable to create the task dependency table",
+				},
+			},
+			Down: []CommandEntry{
+				{
+					"DROP TABLE taskDependencies;",
+					"Unable to drop the task dependency table",
+				},
+			},
+		},
+
+		{
+			Version: 8,
+			Name:    "Add the recurring tasks",
+			Up: []CommandEntry{
+				{
+					`ALTER TABLE tasks ADD COLUMN recurrence STRING NOT NULL DEFAULT "";`,
+					"Unable to add the recurrence column to the tasks table",
+				},
+				{
+					"ALTER TABLE tasks ADD COLUMN recurrenceInterval INTEGER NOT NULL DEFAULT 1;",
+					"Unable to add the recurrence interval column to the tasks table",
+				},
+			},
+			Down: rebuildTable("tasks", 7),
 		},
+
 		{
-			"INSERT INTO tags (name, color) " +
-				"VALUES" +
-				`("Limbo", "#778899");`,
-			"Unable to create the Limbo tag",
+			Version: 9,
+			Name:    "Add the task estimates and the session tasks",
+			Up: []CommandEntry{
+				{
+					"ALTER TABLE tasks ADD COLUMN estimate INTEGER NOT NULL DEFAULT 0;",
+					"Unable to add the estimate column to the tasks table",
+				},
+				{
+					"ALTER TABLE sessions ADD COLUMN taskId INTEGER NOT NULL DEFAULT 0;",
+					"Unable to add the task id column to the sessions table",
+				},
+			},
+			Down: append(rebuildTable("tasks", 8), rebuildTable("sessions", 8)...),
 		},
+
 		{
-			"INSERT INTO tags (id, name, color) " +
-				`SELECT id+1, name, color ` +
-				"FROM _tags_old;",
-			"Unable to copy the rows to the new tags table",
+			Version: 10,
+			Name:    "Add the session notes",
+			Up: []CommandEntry{
+				{
+					`ALTER TABLE sessions ADD COLUMN note STRING NOT NULL DEFAULT "";`,
+					"Unable to add the note column to the sessions table",
+				},
+			},
+			Down: rebuildTable("sessions", 9),
 		},
+
 		{
-			"DROP TABLE _tags_old;",
-			"Unable to drop the old tags table",
+			Version: 11,
+			Name:    "Add the task positions",
+			Up: []CommandEntry{
+				{
+					"ALTER TABLE tasks ADD COLUMN position INTEGER NOT NULL DEFAULT 0;",
+					"Unable to add the position column to the tasks table",
+				},
+				{
+					"UPDATE tasks SET position = id;",
+					"Unable to set the initial task positions",
+				},
+			},
+			Down: rebuildTable("tasks", 10),
 		},
+
 		{
-			`UPDATE tags SET color = "#3cb371" WHERE id = 2`,
-			"Cannot update the color of the Archived tag",
+			Version: 12,
+			Name:    "Add the task tags",
+			Up: []CommandEntry{
+				{
+					"CREATE TABLE taskTags (" +
+						"taskId INTEGER NOT NULL, " +
+						"tagId INTEGER NOT NULL, " +
+						"CONSTRAINT PK_Pair PRIMARY KEY (taskId, tagId)" +
+						"FOREIGN KEY(taskId) REFERENCES tasks(id)," +
+						"FOREIGN KEY(tagId) REFERENCES tags(id));",
+					"Unable to create the task-tag table",
+				},
+			},
+			Down: []CommandEntry{
+				{
+					"DROP TABLE taskTags;",
+					"Unable to drop the task-tag table",
+				},
+			},
 		},
+
 		{
-			"ALTER TABLE projectTags RENAME TO _projectTags_old;",
-			"Unable to rename projectTags to _projectTags_old",
+			Version: 13,
+			Name:    "Add the tag hierarchy",
+			Up: []CommandEntry{
+				{
+					"ALTER TABLE tags ADD COLUMN parentId INTEGER NOT NULL DEFAULT 0;",
+					"Unable to add the parent id column to the tags table",
+				},
+			},
+			Down: rebuildTable("tags", 12),
 		},
+
 		{
-			"CREATE TABLE projectTags (" +
-				"projectId INTEGER NOT NULL, " +
-				"tagId INTEGER NOT NULL, " +
-				"CONSTRAINT PK_Pair PRIMARY KEY (projectId, tagId)" +
-				"FOREIGN KEY(projectId) REFERENCES projects(id)," +
-				"FOREIGN KEY(tagId) REFERENCES tags(id));",
-			"Unable to create the project-tag table.",
+			Version: 14,
+			Name:    "Add the project hierarchy",
+			Up: []CommandEntry{
+				{
+					"ALTER TABLE projects ADD COLUMN parentId INTEGER NOT NULL DEFAULT 0;",
+					"Unable to add the parent id column to the projects table",
+				},
+			},
+			Down: rebuildTable("projects", 13),
+		},
+
+		{
+			Version: 15,
+			Name:    "Add the milestones",
+			Up: []CommandEntry{
+				{
+					"CREATE TABLE milestones (" +
+						"id INTEGER PRIMARY KEY AUTOINCREMENT, " +
+						"projectId INTEGER NOT NULL, " +
+						"name STRING NOT NULL, " +
+						"targetDate INTEGER NOT NULL, " +
+						"FOREIGN KEY(projectId) REFERENCES projects(id));",
+					"Unable to create the milestones table",
+				},
+				{
+					"ALTER TABLE tasks ADD COLUMN milestoneId INTEGER NOT NULL DEFAULT 0;",
+					"Unable to add the milestone id column to the tasks table",
+				},
+			},
+			Down: append([]CommandEntry{
+				{
+					"DROP TABLE milestones;",
+					"Unable to drop the milestones table",
+				},
+			}, rebuildTable("tasks", 14)...),
 		},
+
+		// The Limbo and Archived tags used to stand in for the project status; they
+		// are turned into proper status values and removed. Going back shifts the
+		// ids of the other tags by two to make room for them, the way version 3
+		// did, renames the user's tags that took their names, and forgets the
+		// statuses that have no tag.
 		{
-			"INSERT INTO projectTags (projectId, tagId) " +
-				`SELECT projectId, tagId+1 ` +
-				"FROM _projectTags_old;",
-			"Unable to copy the rows to the new tasks table",
+			Version: 16,
+			Name:    "Replace the Limbo and Archived tags with the project status",
+			Up: []CommandEntry{
+				{
+					`ALTER TABLE projects ADD COLUMN status STRING NOT NULL DEFAULT "active";`,
+					"Unable to add the status column to the projects table",
+				},
+				{
+					"CREATE TABLE projectStatusHistory (" +
+						"id INTEGER PRIMARY KEY AUTOINCREMENT, " +
+						"projectId INTEGER NOT NULL, " +
+						"status STRING NOT NULL, " +
+						"timestamp INTEGER NOT NULL, " +
+						"FOREIGN KEY(projectId) REFERENCES projects(id));",
+					"Unable to create the project status history table",
+				},
+				{
+					`UPDATE projects SET status = "paused" ` +
+						"WHERE id IN (SELECT projectId FROM projectTags WHERE tagId = 1);",
+					"Unable to migrate the Limbo projects",
+				},
+				{
+					`UPDATE projects SET status = "archived" ` +
+						"WHERE id IN (SELECT projectId FROM projectTags WHERE tagId = 2);",
+					"Unable to migrate the Archived projects",
+				},
+				{
+					"INSERT INTO projectStatusHistory (projectId, status, timestamp) " +
+						`SELECT id, status, strftime("%s", "now") FROM projects;`,
+					"Unable to record the initial project status",
+				},
+				{
+					"DELETE FROM projectTags WHERE tagId IN (1, 2);",
+					"Unable to disassociate projects from the built-in tags",
+				},
+				{
+					"DELETE FROM taskTags WHERE tagId IN (1, 2);",
+					"Unable to disassociate tasks from the built-in tags",
+				},
+				{
+					"UPDATE tags SET parentId = 0 WHERE parentId IN (1, 2);",
+					"Unable to reparent the children of the built-in tags",
+				},
+				{
+					"DELETE FROM tags WHERE id IN (1, 2);",
+					"Unable to delete the built-in tags",
+				},
+			},
+			Down: append([]CommandEntry{
+				{
+					"UPDATE tags SET id = -id - 2, " +
+						"parentId = CASE WHEN parentId = 0 THEN 0 ELSE parentId + 2 END;",
+					"Unable to make room for the built-in tags",
+				},
+				{
+					"UPDATE tags SET id = -id;",
+					"Unable to make room for the built-in tags",
+				},
+				{
+					"UPDATE projectTags SET tagId = -tagId - 2;",
+					"Unable to renumber the project tags",
+				},
+				{
+					"UPDATE projectTags SET tagId = -tagId;",
+					"Unable to renumber the project tags",
+				},
+				{
+					"UPDATE taskTags SET tagId = -tagId - 2;",
+					"Unable to renumber the task tags",
+				},
+				{
+					"UPDATE taskTags SET tagId = -tagId;",
+					"Unable to renumber the task tags",
+				},
+				{
+					"UPDATE tags SET name = name || ' (renamed)' " +
+						"WHERE name IN ('Limbo', 'Archived');",
+					"Unable to rename the tags clashing with the built-in tags",
+				},
+				{
+					"INSERT INTO tags (id, name, color, parentId) " +
+						"VALUES (1, 'Limbo', '#778899', 0), (2, 'Archived', '#3cb371', 0);",
+					"Unable to restore the built-in tags",
+				},
+				{
+					"INSERT INTO projectTags (projectId, tagId) " +
+						"SELECT id, 1 FROM projects WHERE status = 'paused';",
+					"Unable to tag the paused projects with Limbo",
+				},
+				{
+					"INSERT INTO projectTags (projectId, tagId) " +
+						"SELECT id, 2 FROM projects WHERE status = 'archived';",
+					"Unable to tag the archived projects with Archived",
+				},
+				{
+					"DROP TABLE projectStatusHistory;",
+					"Unable to drop the project status history table",
+				},
+			}, rebuildTable("projects", 15)...),
 		},
+
 		{
-			"DROP TABLE _projectTags_old;",
-			"Unable to drop the old projectTags table",
+			Version: 17,
+			Name:    "Add the goals",
+			Up: []CommandEntry{
+				{
+					"CREATE TABLE goals (" +
+						"id INTEGER PRIMARY KEY AUTOINCREMENT, " +
+						"projectId INTEGER NOT NULL DEFAULT 0, " +
+						"tagId INTEGER NOT NULL DEFAULT 0, " +
+						"period STRING NOT NULL, " +
+						"minimum INTEGER NOT NULL DEFAULT 0, " +
+						"maximum INTEGER NOT NULL DEFAULT 0);",
+					"Unable to create the goals table",
+				},
+			},
+			Down: []CommandEntry{
+				{
+					"DROP TABLE goals;",
+					"Unable to drop the goals table",
+				},
+			},
 		},
-	}
+	},
+}
 
-	return executeQueries(db, queries)
+// Anything running statements: a connection or a transaction
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
 }
 
-func executeQueries(db *sql.DB, queries []CommandEntry) error {
+func executeQueries(db execer, queries []CommandEntry) error {
 	for _, command := range queries {
 		_, err := db.Exec(command.Query)
 		if err != nil {
@@ -222,13 +710,6 @@ func executeQueries(db *sql.DB, queries []CommandEntry) error {
 
 }
 
-func getUpgraders() map[uint64]func(db *sql.DB) error {
-	upgraders := make(map[uint64]func(db *sql.DB) error)
-	upgraders[1] = upgradeFrom1To2
-	upgraders[2] = upgradeFrom2To3
-	return upgraders
-}
-
 func copyFile(fromFn, toFn string) error {
 	from, err := os.Open(fromFn)
 	if err != nil {
@@ -250,7 +731,13 @@ func copyFile(fromFn, toFn string) error {
 	return nil
 }
 
-func (db *Database) upgrade(dbDir string, fileVersion, currentVersion uint64) error {
+// Back up the database file and migrate it; reverting the migrations goes
+// through here too
+func (db *Database) upgrade(dbDir string, fileVersion, targetVersion uint64) error {
+	if err := db.db.Close(); err != nil {
+		return fmt.Errorf("Unable to close the database: %s", err)
+	}
+
 	t := time.Now()
 	backupFileName := fmt.Sprintf("reef.db-%s-version-%d", t.Format("2006-01-02T15:04:05.999999"), fileVersion)
 	backupFileName = filepath.Join(dbDir, backupFileName)
@@ -262,78 +749,51 @@ func (db *Database) upgrade(dbDir string, fileVersion, currentVersion uint64) er
 		return err
 	}
 
-	db.db, err = sql.Open("sqlite3", dbFile)
-	if err != nil {
+	if err = db.open("sqlite3", dbFile, sqliteDialect{}); err != nil {
 		return err
 	}
 
-	upgraders := getUpgraders()
-	for i := fileVersion; i < currentVersion; i++ {
-		upgrader, ok := upgraders[i]
-		if !ok {
-			return fmt.Errorf("Cannot find database upgrader from version %d to version %d", i, i+1)
-		}
-		if err = upgrader(db.db); err != nil {
-			return fmt.Errorf("Database upgrader from version %d to version %d failed: %s", i, i+1, err.Error())
-		}
-	}
+	return db.migrate(fileVersion, targetVersion)
+}
 
-	_, err = db.db.Exec(fmt.Sprintf(`UPDATE metadata SET value=%d WHERE key="version";`, currentVersion))
+func (db *Database) open(driver, source string, d dialect) error {
+	conn, err := sql.Open(driver, source)
 	if err != nil {
-		return fmt.Errorf("Unable to update version number in the database metadata")
+		return err
 	}
-
+	db.db = &dbConn{conn, nil, d}
 	return nil
 }
 
 func (db *Database) initialize(dbDir string) (err error) {
 	dbFile := filepath.Join(dbDir, "reef.db")
 	log.Infof("Using %s for database storage", dbFile)
-	db.db, err = sql.Open("sqlite3", dbFile)
-	if err != nil {
-		return
-	}
-
-	_, err = db.db.Exec("CREATE TABLE IF NOT EXISTS metadata (key STRING PRIMARY KEY, value STRING)")
-	md, err := db.readMetadata()
-	if err != nil {
-		log.Error("Unable to read matadata from the database: ", err)
-		return
-	}
-
-	var fileVersionStr string
-	var fileVersion uint64
-	var ok bool
-	if fileVersionStr, ok = md["version"]; !ok {
-		log.Info("Creating a new database")
-		err = db.initializeNew()
-		return
-	}
-
-	if fileVersion, err = strconv.ParseUint(fileVersionStr, 10, 64); err != nil {
-		log.Errorf("Unable to parse the version string: %s", err.Error())
+	if err = db.open("sqlite3", dbFile, sqliteDialect{}); err != nil {
 		return
 	}
+	db.dir = dbDir
+	db.migrations = sqliteMigrations
 
-	log.Info("Database version: ", fileVersion)
-
-	if fileVersion != currentVersion {
-		if err = db.db.Close(); err != nil {
-			log.Error("Unable to close the database: ", err)
-			return
-		}
-
-		if err = db.upgrade(dbDir, fileVersion, currentVersion); err != nil {
-			log.Error("Unable to upgrade the database: ", err)
-			return
-		}
+	queries := []CommandEntry{
+		{
+			"CREATE TABLE IF NOT EXISTS metadata (key STRING PRIMARY KEY, value STRING);",
+			"Unable to create the metadata table",
+		},
+		{
+			"CREATE TABLE IF NOT EXISTS migrationHistory (" +
+				"id INTEGER PRIMARY KEY AUTOINCREMENT, " +
+				"version INTEGER NOT NULL, " +
+				"direction STRING NOT NULL, " +
+				"timestamp INTEGER NOT NULL);",
+			"Unable to create the migration history table",
+		},
 	}
-	return
+	return executeQueries(db.db, queries)
 }
 
 func (db *Database) GetProjectsByTag(tagId uint64) ([]uint64, error) {
 	projectIds := []uint64{}
-	query := "SELECT projectId FROM projectTags WHERE tagId = ?;"
+	query := "SELECT projectId FROM projectTags WHERE tagId = ? ORDER BY projectId;"
 	rows, err := db.db.Query(query, tagId)
 	if err != nil {
 		return []uint64{}, fmt.Errorf("Cannot query project ids: %s", err.Error())
@@ -352,10 +812,33 @@ func (db *Database) GetProjectsByTag(tagId uint64) ([]uint64, error) {
 	return projectIds, nil
 }
 
+const tagSubtreeQuery = "WITH RECURSIVE subtree(id) AS (" +
+	"SELECT CAST(? AS BIGINT) UNION ALL " +
+	"SELECT tags.id FROM tags JOIN subtree ON tags.parentId = subtree.id) "
+
+// Get the ids of the tag and all of its descendants
+func (db *Database) GetTagSubtreeIds(id uint64) ([]uint64, error) {
+	return db.getIdsById(tagSubtreeQuery+"SELECT id FROM subtree;", id)
+}
+
+func (db *Database) GetTagChildIds(id uint64) ([]uint64, error) {
+	return db.getIdsById("SELECT id FROM tags WHERE parentId = ? ORDER BY id;", id)
+}
+
+// Get the ids of all the ancestors of the tag, starting with its parent
+func (db *Database) GetTagAncestorIds(id uint64) ([]uint64, error) {
+	query := "WITH RECURSIVE ancestors(id, depth) AS (" +
+		"SELECT parentId, 1 FROM tags WHERE id = ? UNION ALL " +
+		"SELECT tags.parentId, ancestors.depth + 1 FROM tags " +
+		"JOIN ancestors ON tags.id = ancestors.id) " +
+		"SELECT id FROM ancestors WHERE id != 0 ORDER BY depth;"
+	return db.getIdsById(query, id)
+}
+
 func (db *Database) GetTagById(id uint64) (Tag, error) {
-	query := "SELECT id, name, color FROM tags WHERE id = ?;"
+	query := "SELECT id, name, color, parentId FROM tags WHERE id = ?;"
 	var tag Tag
-	err := db.db.QueryRow(query, id).Scan(&tag.Id, &tag.Name, &tag.Color)
+	err := db.db.QueryRow(query, id).Scan(&tag.Id, &tag.Name, &tag.Color, &tag.ParentId)
 	if err != nil {
 		return Tag{}, fmt.Errorf("Cannot query tag: %s", err.Error())
 	}
@@ -367,24 +850,38 @@ func (db *Database) GetTagById(id uint64) (Tag, error) {
 
 	tag.NumberOfProjects = uint32(len(projectIds))
 
-	for _, projectId := range projectIds {
-		var sInfo SessionsInfo
-		if sInfo, err = db.GetProjectSessions(projectId); err != nil {
-			return Tag{}, err
-		}
-		tag.DurationTotal += sInfo.DurationTotal
-		tag.DurationMonth += sInfo.DurationMonth
-		tag.DurationWeek += sInfo.DurationWeek
+	query = "SELECT COUNT(*) FROM taskTags WHERE tagId = ?;"
+	if err = db.db.QueryRow(query, id).Scan(&tag.NumberOfTasks); err != nil {
+		return Tag{}, fmt.Errorf("Cannot count tasks of tag: %s", err.Error())
+	}
+
+	// The time spent rolls up the tag tree; a project tagged with more than
+	// one tag of the subtree is counted once
+	monthStart, weekStart := db.periods.Bounds(time.Now())
+	query = tagSubtreeQuery + "SELECT COALESCE(SUM(duration), 0), " +
+		"COALESCE(SUM(CASE WHEN sessions.timestamp >= ? THEN duration END), 0), " +
+		"COALESCE(SUM(CASE WHEN sessions.timestamp >= ? THEN duration END), 0) FROM sessions " +
+		"WHERE projectId IN (SELECT projectId FROM projectTags " +
+		"WHERE tagId IN (SELECT id FROM subtree));"
+	err = db.db.QueryRow(query, id, monthStart.Unix(), weekStart.Unix()).Scan(
+		&tag.DurationTotal, &tag.DurationMonth, &tag.DurationWeek)
+	if err != nil {
+		return Tag{}, fmt.Errorf("Cannot compute the time spent on tag: %s", err.Error())
+	}
+
+	durations := Durations{tag.DurationTotal, tag.DurationMonth, tag.DurationWeek}
+	if tag.Goals, err = db.getGoals("tagId", id, durations); err != nil {
+		return Tag{}, err
 	}
 
 	return tag, nil
 }
 
 func (db *Database) GetSummaryById(id uint64) (Summary, error) {
-	query := "SELECT id, title FROM projects WHERE id = ?;"
+	query := "SELECT id, title, parentId, status FROM projects WHERE id = ?;"
 	var summary Summary
 	row := db.db.QueryRow(query, id)
-	if err := row.Scan(&summary.Id, &summary.Title); err != nil {
+	if err := row.Scan(&summary.Id, &summary.Title, &summary.ParentId, &summary.Status); err != nil {
 		return Summary{}, err
 	}
 
@@ -440,16 +937,16 @@ func (db *Database) getIdsById(query string, id uint64) ([]uint64, error) {
 }
 
 func (db *Database) GetTagIdsByProjectId(id uint64) ([]uint64, error) {
-	return db.getIdsById("SELECT tagId FROM projectTags WHERE projectId = ?;", id)
+	return db.getIdsById("SELECT tagId FROM projectTags WHERE projectId = ? ORDER BY tagId;", id)
 }
 
 func (db *Database) GetProjectIdsByTagId(id uint64) ([]uint64, error) {
-	return db.getIdsById("SELECT projectId FROM projectTags WHERE tagId = ?;", id)
+	return db.getIdsById("SELECT projectId FROM projectTags WHERE tagId = ? ORDER BY projectId;", id)
 }
 
 func (db *Database) GetAllTagIds() ([]uint64, error) {
 	tagIds := []uint64{}
-	rows, err := db.db.Query("SELECT id FROM tags;")
+	rows, err := db.db.Query("SELECT id FROM tags ORDER BY id;")
 	if err != nil {
 		return []uint64{}, err
 	}
@@ -467,20 +964,57 @@ func (db *Database) GetAllTagIds() ([]uint64, error) {
 	return tagIds, nil
 }
 
-func (db *Database) GetProjectTasks(id uint64) ([]Task, error) {
+// The columns scanned by queryTasks
+func (db *Database) taskColumns() string {
+	return "id, projectId, parentId, done, priority, title, description, " +
+		"startDate, dueDate, recurrence, recurrenceInterval, estimate, position, milestoneId, " +
+		"(SELECT " + db.db.dialect.groupConcat("tagId", ",") +
+		" FROM taskTags WHERE taskId = tasks.id), " +
+		"(SELECT " + db.db.dialect.groupConcat("blockerId", ",") +
+		" FROM taskDependencies WHERE taskId = tasks.id), " +
+		"EXISTS (SELECT 1 FROM taskDependencies JOIN tasks AS blockers " +
+		"ON blockers.id = taskDependencies.blockerId " +
+		"WHERE taskDependencies.taskId = tasks.id AND blockers.done = FALSE)"
+}
+
+func parseIdList(lst sql.NullString) ([]uint64, error) {
+	ids := []uint64{}
+	if !lst.Valid || lst.String == "" {
+		return ids, nil
+	}
+	for _, idStr := range strings.Split(lst.String, ",") {
+		id, err := strconv.ParseUint(idStr, 10, 64)
+		if err != nil {
+			return []uint64{}, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (db *Database) queryTasks(query string, args ...interface{}) ([]Task, error) {
 	tasks := []Task{}
-	query := "SELECT id, done, priority, title, description FROM tasks WHERE projectId = ?;"
-	rows, err := db.db.Query(query, id)
+	rows, err := db.db.Query(query, args...)
 	if err != nil {
 		return []Task{}, err
 	}
 	for rows.Next() {
 		var task Task
-		err := rows.Scan(&task.Id, &task.Done, &task.Priority, &task.Title, &task.Description)
+		var tags sql.NullString
+		var blockers sql.NullString
+		err := rows.Scan(&task.Id, &task.ProjectId, &task.ParentId, &task.Done,
+			&task.Priority, &task.Title, &task.Description, &task.StartDate, &task.DueDate,
+			&task.Recurrence, &task.RecurrenceInterval, &task.Estimate, &task.Position,
+			&task.MilestoneId, &tags, &blockers, &task.Blocked)
 		if err != nil {
 			return []Task{}, err
 		}
-		task.ProjectId = id
+		if task.Tags, err = parseIdList(tags); err != nil {
+			return []Task{}, err
+		}
+		if task.BlockedBy, err = parseIdList(blockers); err != nil {
+			return []Task{}, err
+		}
 		tasks = append(tasks, task)
 	}
 	if err := rows.Err(); err != nil {
@@ -489,61 +1023,191 @@ func (db *Database) GetProjectTasks(id uint64) ([]Task, error) {
 	return tasks, nil
 }
 
+func (db *Database) GetProjectTasks(id uint64) ([]Task, error) {
+	query := "SELECT " + db.taskColumns() + " FROM tasks WHERE projectId = ? ORDER BY position, id;"
+	return db.queryTasks(query, id)
+}
+
+// Get the unfinished tasks that are past their due date and the ones that
+// are due within the given number of days
+func (db *Database) GetDueTasks(days uint64) (DueTasks, error) {
+	var dueTasks DueTasks
+	now := time.Now()
+	horizon := now.AddDate(0, 0, int(days))
+
+	query := "SELECT " + db.taskColumns() + " FROM tasks " +
+		"WHERE done = FALSE AND dueDate != 0 AND dueDate < ? ORDER BY dueDate, id;"
+	var err error
+	if dueTasks.Overdue, err = db.queryTasks(query, now.Unix()); err != nil {
+		return DueTasks{}, fmt.Errorf("Unable to query overdue tasks: %s", err)
+	}
+
+	query = "SELECT " + db.taskColumns() + " FROM tasks " +
+		"WHERE done = FALSE AND dueDate >= ? AND dueDate < ? ORDER BY dueDate, id;"
+	if dueTasks.Upcoming, err = db.queryTasks(query, now.Unix(), horizon.Unix()); err != nil {
+		return DueTasks{}, fmt.Errorf("Unable to query upcoming tasks: %s", err)
+	}
+	return dueTasks, nil
+}
+
+// Compute the completeness of a task tree. Every top-level task weighs the
+// same; the weight of a task that has subtasks is split evenly between them,
+// unless the task itself is marked as done.
+func computeCompleteness(tasks []Task) float32 {
+	children := make(map[uint64][]Task)
+	known := make(map[uint64]bool)
+	for _, task := range tasks {
+		known[task.Id] = true
+	}
+
+	roots := []Task{}
+	for _, task := range tasks {
+		if task.ParentId == 0 || !known[task.ParentId] {
+			roots = append(roots, task)
+		} else {
+			children[task.ParentId] = append(children[task.ParentId], task)
+		}
+	}
+
+	var completeness func(task Task) float32
+	completeness = func(task Task) float32 {
+		if task.Done {
+			return 1
+		}
+		subtasks := children[task.Id]
+		if len(subtasks) == 0 {
+			return 0
+		}
+		var sum float32
+		for _, subtask := range subtasks {
+			sum += completeness(subtask)
+		}
+		return sum / float32(len(subtasks))
+	}
+
+	if len(roots) == 0 {
+		return 1
+	}
+
+	var sum float32
+	for _, root := range roots {
+		sum += completeness(root)
+	}
+	return sum / float32(len(roots))
+}
+
+// Compute the completeness of the project including its sub-projects; the
+// project's own tasks and each of the sub-projects weigh the same
 func (db *Database) GetProjectCompleteness(id uint64) (float32, error) {
-	query := "SELECT COUNT(*) FROM tasks WHERE projectId = ?"
-	var all uint64
-	if err := db.db.QueryRow(query, id).Scan(&all); err != nil {
+	tasks, err := db.GetProjectTasks(id)
+	if err != nil {
 		return 0, err
 	}
 
-	query = "SELECT COUNT(*) FROM tasks WHERE projectId = ? AND done = TRUE"
-	var done uint64
-	if err := db.db.QueryRow(query, id).Scan(&done); err != nil {
+	children, err := db.GetProjectChildIds(id)
+	if err != nil {
 		return 0, err
 	}
 
-	if all == 0 {
-		return 1, nil
+	if len(children) == 0 {
+		return computeCompleteness(tasks), nil
+	}
+
+	var sum float32
+	var parts uint64
+	if len(tasks) != 0 {
+		sum += computeCompleteness(tasks)
+		parts++
+	}
+
+	for _, childId := range children {
+		completeness, err := db.GetProjectCompleteness(childId)
+		if err != nil {
+			return 0, err
+		}
+		sum += completeness
+		parts++
 	}
-	return float32(done) / float32(all), nil
+	return sum / float32(parts), nil
+}
+
+const projectSubtreeQuery = "WITH RECURSIVE subtree(id) AS (" +
+	"SELECT CAST(? AS BIGINT) UNION ALL " +
+	"SELECT projects.id FROM projects JOIN subtree ON projects.parentId = subtree.id) "
+
+// Get the ids of the project and all of its descendants
+func (db *Database) GetProjectSubtreeIds(id uint64) ([]uint64, error) {
+	return db.getIdsById(projectSubtreeQuery+"SELECT id FROM subtree;", id)
+}
+
+func (db *Database) GetProjectChildIds(id uint64) ([]uint64, error) {
+	return db.getIdsById("SELECT id FROM projects WHERE parentId = ? ORDER BY id;", id)
+}
+
+// Get the ids of all the ancestors of the project, starting with its parent
+func (db *Database) GetProjectAncestorIds(id uint64) ([]uint64, error) {
+	query := "WITH RECURSIVE ancestors(id, depth) AS (" +
+		"SELECT parentId, 1 FROM projects WHERE id = ? UNION ALL " +
+		"SELECT projects.parentId, ancestors.depth + 1 FROM projects " +
+		"JOIN ancestors ON projects.id = ancestors.id) " +
+		"SELECT id FROM ancestors WHERE id != 0 ORDER BY depth;"
+	return db.getIdsById(query, id)
+}
+
+// Get the ids of the task and all of its descendants
+func (db *Database) GetSubtaskIds(id uint64) ([]uint64, error) {
+	query := "WITH RECURSIVE subtree(id) AS (" +
+		"SELECT CAST(? AS BIGINT) UNION ALL " +
+		"SELECT tasks.id FROM tasks JOIN subtree ON tasks.parentId = subtree.id) " +
+		"SELECT id FROM subtree;"
+	return db.getIdsById(query, id)
 }
 
 type SessionsInfo struct {
-	DurationTotal uint64
-	DurationMonth uint64
-	DurationWeek  uint64
+	Durations
 	Sessions      []Session
+	TaskDurations map[uint64]Durations
+}
+
+func (d *Durations) add(duration uint64, dt, monthStart, weekStart time.Time) {
+	d.DurationTotal += duration
+
+	if !dt.Before(monthStart) {
+		d.DurationMonth += duration
+	}
+
+	if !dt.Before(weekStart) {
+		d.DurationWeek += duration
+	}
 }
 
 func (db *Database) GetProjectSessions(projectId uint64) (SessionsInfo, error) {
 	var sInfo SessionsInfo
 	sInfo.Sessions = []Session{}
-	monthAgo := time.Now().AddDate(0, -1, 0)
-	weekAgo := time.Now().AddDate(0, 0, -7)
-	query := "SELECT id, timestamp, duration FROM sessions WHERE projectId = ?"
+	sInfo.TaskDurations = make(map[uint64]Durations)
+	monthStart, weekStart := db.periods.Bounds(time.Now())
+	query := "SELECT id, taskId, timestamp, duration, note FROM sessions WHERE projectId = ? " +
+		"ORDER BY id;"
 	rows, err := db.db.Query(query, projectId)
 	if err != nil {
 		return SessionsInfo{}, fmt.Errorf("Can't get project sessions: %s", err.Error())
 	}
 	for rows.Next() {
 		var session Session
-		var dt time.Time
-		err := rows.Scan(&session.Id, &dt, &session.Duration)
+		var dt sessionTime
+		err := rows.Scan(&session.Id, &session.TaskId, &dt, &session.Duration, &session.Note)
 		if err != nil {
 			return SessionsInfo{},
 				fmt.Errorf("Can't parse project sessions: %s", err.Error())
 		}
 		session.Date = uint64(dt.Unix())
 		sInfo.Sessions = append(sInfo.Sessions, session)
+		sInfo.add(session.Duration, dt.Time, monthStart, weekStart)
 
-		sInfo.DurationTotal += session.Duration
-
-		if dt.After(monthAgo) {
-			sInfo.DurationMonth += session.Duration
-		}
-
-		if dt.After(weekAgo) {
-			sInfo.DurationWeek += session.Duration
+		if session.TaskId != 0 {
+			taskDurations := sInfo.TaskDurations[session.TaskId]
+			taskDurations.add(session.Duration, dt.Time, monthStart, weekStart)
+			sInfo.TaskDurations[session.TaskId] = taskDurations
 		}
 	}
 	if err := rows.Err(); err != nil {
@@ -553,70 +1217,484 @@ func (db *Database) GetProjectSessions(projectId uint64) (SessionsInfo, error) {
 	return sInfo, nil
 }
 
-func (db *Database) GetProjectById(id uint64) (Project, error) {
-	query := "SELECT id, title, description FROM projects WHERE id = ?;"
-	var project Project
-	err := db.db.QueryRow(query, id).Scan(&project.Id, &project.Title, &project.Description)
+// Get the milestones of the project; the completeness of each milestone is
+// computed over the tasks assigned to it together with their subtasks
+func (db *Database) GetProjectMilestones(projectId uint64, tasks []Task) ([]Milestone, error) {
+	milestones := []Milestone{}
+	query := "SELECT id, projectId, name, targetDate FROM milestones " +
+		"WHERE projectId = ? ORDER BY targetDate, id;"
+	rows, err := db.db.Query(query, projectId)
 	if err != nil {
-		return Project{}, err
+		return []Milestone{}, fmt.Errorf("Cannot query milestones: %s", err.Error())
+	}
+	for rows.Next() {
+		var milestone Milestone
+		err := rows.Scan(&milestone.Id, &milestone.ProjectId, &milestone.Name,
+			&milestone.TargetDate)
+		if err != nil {
+			return []Milestone{}, fmt.Errorf("Cannot scan milestones: %s", err.Error())
+		}
+		milestones = append(milestones, milestone)
+	}
+	if err := rows.Err(); err != nil {
+		return []Milestone{}, fmt.Errorf("Cannot process milestones: %s", err.Error())
 	}
 
-	if project.Tags, err = db.GetTagIdsByProjectId(id); err != nil {
-		return Project{}, err
+	computeMilestones(milestones, tasks)
+	return milestones, nil
+}
+
+// Fill in the tasks and the completeness of the milestones
+func computeMilestones(milestones []Milestone, tasks []Task) {
+	taskMap := make(map[uint64]Task)
+	for _, task := range tasks {
+		taskMap[task.Id] = task
 	}
 
-	if project.Tasks, err = db.GetProjectTasks(id); err != nil {
-		return Project{}, err
+	// Subtasks belong to the milestone of their closest assigned ancestor
+	var milestoneOf func(task Task) uint64
+	milestoneOf = func(task Task) uint64 {
+		if task.MilestoneId != 0 {
+			return task.MilestoneId
+		}
+		if parent, ok := taskMap[task.ParentId]; ok && task.ParentId != 0 {
+			return milestoneOf(parent)
+		}
+		return 0
 	}
 
-	if project.Completeness, err = db.GetProjectCompleteness(id); err != nil {
-		return Project{}, err
+	milestoneTasks := make(map[uint64][]Task)
+	for _, task := range tasks {
+		if id := milestoneOf(task); id != 0 {
+			milestoneTasks[id] = append(milestoneTasks[id], task)
+		}
 	}
 
-	var sInfo SessionsInfo
-	if sInfo, err = db.GetProjectSessions(id); err != nil {
-		return Project{}, err
+	for i := range milestones {
+		milestones[i].Tasks = []uint64{}
+		for _, task := range milestoneTasks[milestones[i].Id] {
+			if task.MilestoneId != 0 {
+				milestones[i].Tasks = append(milestones[i].Tasks, task.Id)
+			}
+		}
+		milestones[i].Completeness = computeCompleteness(milestoneTasks[milestones[i].Id])
 	}
-	project.Sessions = sInfo.Sessions
-	project.DurationTotal = sInfo.DurationTotal
-	project.DurationMonth = sInfo.DurationMonth
-	project.DurationWeek = sInfo.DurationWeek
+}
 
-	return project, nil
+func (db *Database) CreateMilestone(projectId uint64, name string, targetDate uint64) error {
+	return db.transaction(func(tx *Database) error {
+		return tx.createMilestone(projectId, name, targetDate)
+	})
 }
 
-func (db *Database) GetSummaryList() ([]Summary, error) {
-	summaries := []Summary{}
-	rows, err := db.db.Query("SELECT id, title FROM projects;")
-	if err != nil {
-		return []Summary{}, err
-	}
-	for rows.Next() {
-		var summary Summary
-		err := rows.Scan(&summary.Id, &summary.Title)
-		if err != nil {
-			return []Summary{}, err
-		}
-		summary.Tags, err = db.GetTagIdsByProjectId(summary.Id)
-		if err != nil {
-			return []Summary{}, err
-		}
-		summary.Completeness, err = db.GetProjectCompleteness(summary.Id)
-		if err != nil {
-			return []Summary{}, err
-		}
-		summaries = append(summaries, summary)
+func (db *Database) createMilestone(projectId uint64, name string, targetDate uint64) error {
+	query := "SELECT id FROM projects WHERE id = ?;"
+	if err := db.db.QueryRow(query, projectId).Scan(&projectId); err != nil {
+		return fmt.Errorf("Unable to find project %d: %s", projectId, err.Error())
 	}
-	if err := rows.Err(); err != nil {
-		return []Summary{}, err
+
+	query = "INSERT INTO milestones (projectId, name, targetDate) VALUES (?, ?, ?);"
+	if _, err := db.db.Exec(query, projectId, name, targetDate); err != nil {
+		return fmt.Errorf("Unable to create milestone: %s", err.Error())
 	}
-	return summaries, nil
+	return nil
 }
 
-func (db *Database) CreateTag(name string, color string) (uint64, error) {
-	_, err := db.db.Exec("INSERT INTO tags (name, color) VALUES (?, ?);", name, color)
+func (db *Database) EditMilestone(id uint64, name string,
+	targetDate uint64) (projectId uint64, err error) {
+	err = db.transaction(func(tx *Database) error {
+		projectId, err = tx.editMilestone(id, name, targetDate)
+		return err
+	})
+	return
+}
+
+func (db *Database) editMilestone(id uint64, name string, targetDate uint64) (uint64, error) {
+	query := "SELECT projectId FROM milestones WHERE id = ?"
+	var projectId uint64
+	if err := db.db.QueryRow(query, id).Scan(&projectId); err != nil {
+		return 0, fmt.Errorf("Unable to find milestone %d: %s", id, err.Error())
+	}
+
+	query = "UPDATE milestones SET name=?, targetDate=? WHERE id=?;"
+	if _, err := db.db.Exec(query, name, targetDate, id); err != nil {
+		return 0, fmt.Errorf("Unable to edit milestone: %s", err.Error())
+	}
+	return projectId, nil
+}
+
+func (db *Database) DeleteMilestone(id uint64) (projectId uint64, err error) {
+	err = db.transaction(func(tx *Database) error {
+		projectId, err = tx.deleteMilestone(id)
+		return err
+	})
+	return
+}
+
+func (db *Database) deleteMilestone(id uint64) (uint64, error) {
+	query := "SELECT projectId FROM milestones WHERE id = ?"
+	var projectId uint64
+	if err := db.db.QueryRow(query, id).Scan(&projectId); err != nil {
+		return 0, fmt.Errorf("Unable to find milestone %d: %s", id, err.Error())
+	}
+
+	query = "UPDATE tasks SET milestoneId = 0 WHERE milestoneId = ?;"
+	if _, err := db.db.Exec(query, id); err != nil {
+		return 0, fmt.Errorf("Unable to unassign tasks from milestone %d: %s", id, err)
+	}
+
+	query = "DELETE FROM milestones WHERE id = ?;"
+	if _, err := db.db.Exec(query, id); err != nil {
+		return 0, fmt.Errorf("Unable to delete milestone: %s", err.Error())
+	}
+	return projectId, nil
+}
+
+// Assign the task to a milestone of its project, or unassign it if the
+// milestone id is zero
+func (db *Database) AssignMilestone(taskId, milestoneId uint64) (projectId uint64, err error) {
+	err = db.transaction(func(tx *Database) error {
+		projectId, err = tx.assignMilestone(taskId, milestoneId)
+		return err
+	})
+	return
+}
+
+func (db *Database) assignMilestone(taskId, milestoneId uint64) (uint64, error) {
+	query := "SELECT projectId FROM tasks WHERE id = ?"
+	var projectId uint64
+	if err := db.db.QueryRow(query, taskId).Scan(&projectId); err != nil {
+		return 0, fmt.Errorf("Unable to find task %d: %s", taskId, err.Error())
+	}
+
+	if milestoneId != 0 {
+		query = "SELECT projectId FROM milestones WHERE id = ?"
+		var milestoneProjectId uint64
+		if err := db.db.QueryRow(query, milestoneId).Scan(&milestoneProjectId); err != nil {
+			return 0, fmt.Errorf("Unable to find milestone %d: %s", milestoneId, err.Error())
+		}
+		if milestoneProjectId != projectId {
+			return 0, fmt.Errorf("Milestone %d belongs to a different project", milestoneId)
+		}
+	}
+
+	query = "UPDATE tasks SET milestoneId=? WHERE id=?;"
+	if _, err := db.db.Exec(query, milestoneId, taskId); err != nil {
+		return 0, fmt.Errorf("Unable to assign task to milestone: %s", err.Error())
+	}
+	return projectId, nil
+}
+
+const (
+	GoalWeek  = "week"
+	GoalMonth = "month"
+)
+
+// Compare the time spent in the period of the goal against its limits
+func computeGoalProgress(goal *Goal, durations Durations) {
+	goal.Spent = durations.DurationWeek
+	if goal.Period == GoalMonth {
+		goal.Spent = durations.DurationMonth
+	}
+
+	goal.Met = goal.Spent >= goal.Minimum
+	if goal.Minimum != 0 {
+		goal.Progress = float32(goal.Spent) / float32(goal.Minimum)
+	}
+	goal.OverBudget = goal.Maximum != 0 && goal.Spent > goal.Maximum
+}
+
+// Get the goals of a project or of a tag depending on the owner column
+func (db *Database) getGoals(owner string, id uint64, durations Durations) ([]Goal, error) {
+	goals := []Goal{}
+	query := "SELECT id, projectId, tagId, period, minimum, maximum FROM goals " +
+		"WHERE " + owner + " = ? ORDER BY id;"
+	rows, err := db.db.Query(query, id)
+	if err != nil {
+		return []Goal{}, fmt.Errorf("Cannot query goals: %s", err.Error())
+	}
+	for rows.Next() {
+		var goal Goal
+		err := rows.Scan(&goal.Id, &goal.ProjectId, &goal.TagId, &goal.Period,
+			&goal.Minimum, &goal.Maximum)
+		if err != nil {
+			return []Goal{}, fmt.Errorf("Cannot scan goals: %s", err.Error())
+		}
+		computeGoalProgress(&goal, durations)
+		goals = append(goals, goal)
+	}
+	if err := rows.Err(); err != nil {
+		return []Goal{}, fmt.Errorf("Cannot process goals: %s", err.Error())
+	}
+	return goals, nil
+}
+
+func checkGoal(period string, minimum, maximum uint64) error {
+	if period != GoalWeek && period != GoalMonth {
+		return fmt.Errorf("Unknown goal period: %s", period)
+	}
+	if minimum == 0 && maximum == 0 {
+		return fmt.Errorf("A goal needs a minimum or a maximum")
+	}
+	if maximum != 0 && maximum < minimum {
+		return fmt.Errorf("The maximum of a goal cannot be lower than its minimum")
+	}
+	return nil
+}
+
+// Create a goal for either a project or a tag; the limits are in minutes
+func (db *Database) CreateGoal(projectId, tagId uint64, period string,
+	minimum, maximum uint64) error {
+	return db.transaction(func(tx *Database) error {
+		return tx.createGoal(projectId, tagId, period, minimum, maximum)
+	})
+}
+
+func (db *Database) createGoal(projectId, tagId uint64, period string,
+	minimum, maximum uint64) error {
+	if (projectId == 0) == (tagId == 0) {
+		return fmt.Errorf("A goal needs either a project or a tag")
+	}
+
+	if err := checkGoal(period, minimum, maximum); err != nil {
+		return err
+	}
+
+	if projectId != 0 {
+		query := "SELECT id FROM projects WHERE id = ?;"
+		if err := db.db.QueryRow(query, projectId).Scan(&projectId); err != nil {
+			return fmt.Errorf("Unable to find project %d: %s", projectId, err.Error())
+		}
+	} else {
+		query := "SELECT id FROM tags WHERE id = ?;"
+		if err := db.db.QueryRow(query, tagId).Scan(&tagId); err != nil {
+			return fmt.Errorf("Unable to find tag %d: %s", tagId, err.Error())
+		}
+	}
+
+	query := "INSERT INTO goals (projectId, tagId, period, minimum, maximum) " +
+		"VALUES (?, ?, ?, ?, ?);"
+	_, err := db.db.Exec(query, projectId, tagId, period, minimum, maximum)
+	if err != nil {
+		return fmt.Errorf("Unable to create goal: %s", err.Error())
+	}
+	return nil
+}
+
+// Returns the ids of the project and of the tag owning the goal
+func (db *Database) EditGoal(id uint64, period string,
+	minimum, maximum uint64) (projectId, tagId uint64, err error) {
+	err = db.transaction(func(tx *Database) error {
+		projectId, tagId, err = tx.editGoal(id, period, minimum, maximum)
+		return err
+	})
+	return
+}
+
+func (db *Database) editGoal(id uint64, period string,
+	minimum, maximum uint64) (uint64, uint64, error) {
+	query := "SELECT projectId, tagId FROM goals WHERE id = ?;"
+	var projectId, tagId uint64
+	if err := db.db.QueryRow(query, id).Scan(&projectId, &tagId); err != nil {
+		return 0, 0, fmt.Errorf("Unable to find goal %d: %s", id, err.Error())
+	}
+
+	if err := checkGoal(period, minimum, maximum); err != nil {
+		return 0, 0, err
+	}
+
+	query = "UPDATE goals SET period=?, minimum=?, maximum=? WHERE id=?;"
+	if _, err := db.db.Exec(query, period, minimum, maximum, id); err != nil {
+		return 0, 0, fmt.Errorf("Unable to edit goal: %s", err.Error())
+	}
+	return projectId, tagId, nil
+}
+
+// Returns the ids of the project and of the tag owning the goal
+func (db *Database) DeleteGoal(id uint64) (projectId, tagId uint64, err error) {
+	err = db.transaction(func(tx *Database) error {
+		projectId, tagId, err = tx.deleteGoal(id)
+		return err
+	})
+	return
+}
+
+func (db *Database) deleteGoal(id uint64) (uint64, uint64, error) {
+	query := "SELECT projectId, tagId FROM goals WHERE id = ?;"
+	var projectId, tagId uint64
+	if err := db.db.QueryRow(query, id).Scan(&projectId, &tagId); err != nil {
+		return 0, 0, fmt.Errorf("Unable to find goal %d: %s", id, err.Error())
+	}
+
+	if _, err := db.db.Exec("DELETE FROM goals WHERE id = ?;", id); err != nil {
+		return 0, 0, fmt.Errorf("Unable to delete goal: %s", err.Error())
+	}
+	return projectId, tagId, nil
+}
+
+func (db *Database) GetProjectById(id uint64) (Project, error) {
+	query := "SELECT id, title, description, parentId, status FROM projects WHERE id = ?;"
+	var project Project
+	err := db.db.QueryRow(query, id).Scan(&project.Id, &project.Title, &project.Description,
+		&project.ParentId, &project.Status)
+	if err != nil {
+		return Project{}, err
+	}
+
+	if project.StatusHistory, err = db.GetProjectStatusHistory(id); err != nil {
+		return Project{}, err
+	}
+
+	if project.Tags, err = db.GetTagIdsByProjectId(id); err != nil {
+		return Project{}, err
+	}
+
+	if project.Tasks, err = db.GetProjectTasks(id); err != nil {
+		return Project{}, err
+	}
+
+	if project.Completeness, err = db.GetProjectCompleteness(id); err != nil {
+		return Project{}, err
+	}
+
+	if project.Milestones, err = db.GetProjectMilestones(id, project.Tasks); err != nil {
+		return Project{}, err
+	}
+
+	var sInfo SessionsInfo
+	if sInfo, err = db.GetProjectSessions(id); err != nil {
+		return Project{}, err
+	}
+	project.Sessions = sInfo.Sessions
+	project.DurationTotal = sInfo.DurationTotal
+	project.DurationMonth = sInfo.DurationMonth
+	project.DurationWeek = sInfo.DurationWeek
+
+	for i := range project.Tasks {
+		project.Tasks[i].Durations = sInfo.TaskDurations[project.Tasks[i].Id]
+	}
+
+	// The time spent on the sub-projects counts towards the totals
+	subtree, err := db.GetProjectSubtreeIds(id)
+	if err != nil {
+		return Project{}, err
+	}
+
+	for _, projectId := range subtree {
+		if projectId == id {
+			continue
+		}
+		if sInfo, err = db.GetProjectSessions(projectId); err != nil {
+			return Project{}, err
+		}
+		project.DurationTotal += sInfo.DurationTotal
+		project.DurationMonth += sInfo.DurationMonth
+		project.DurationWeek += sInfo.DurationWeek
+	}
+
+	durations := Durations{project.DurationTotal, project.DurationMonth, project.DurationWeek}
+	if project.Goals, err = db.getGoals("projectId", id, durations); err != nil {
+		return Project{}, err
+	}
+
+	return project, nil
+}
+
+func (db *Database) GetSummaryList() ([]Summary, error) {
+	summaries := []Summary{}
+	rows, err := db.db.Query("SELECT id, title, parentId, status FROM projects ORDER BY id;")
+	if err != nil {
+		return []Summary{}, err
+	}
+	for rows.Next() {
+		var summary Summary
+		err := rows.Scan(&summary.Id, &summary.Title, &summary.ParentId, &summary.Status)
+		if err != nil {
+			return []Summary{}, err
+		}
+		summary.Tags, err = db.GetTagIdsByProjectId(summary.Id)
+		if err != nil {
+			return []Summary{}, err
+		}
+		summary.Completeness, err = db.GetProjectCompleteness(summary.Id)
+		if err != nil {
+			return []Summary{}, err
+		}
+		summaries = append(summaries, summary)
+	}
+	if err := rows.Err(); err != nil {
+		return []Summary{}, err
+	}
+	return summaries, nil
+}
+
+// Arrange the project summaries into a forest following the project hierarchy
+func (db *Database) GetSummaryTree() ([]SummaryNode, error) {
+	summaries, err := db.GetSummaryList()
+	if err != nil {
+		return []SummaryNode{}, err
+	}
+	return buildSummaryTree(summaries), nil
+}
+
+func buildSummaryTree(summaries []Summary) []SummaryNode {
+	known := make(map[uint64]bool)
+	for _, summary := range summaries {
+		known[summary.Id] = true
+	}
+
+	children := make(map[uint64][]Summary)
+	for _, summary := range summaries {
+		parentId := summary.ParentId
+		if !known[parentId] {
+			parentId = 0
+		}
+		children[parentId] = append(children[parentId], summary)
+	}
+
+	var build func(parentId uint64) []SummaryNode
+	build = func(parentId uint64) []SummaryNode {
+		nodes := []SummaryNode{}
+		for _, summary := range children[parentId] {
+			nodes = append(nodes, SummaryNode{summary, build(summary.Id)})
+		}
+		return nodes
+	}
+
+	return build(0)
+}
+
+func (db *Database) CreateTag(name string, color string, parentId uint64) (id uint64, err error) {
+	err = db.transaction(func(tx *Database) error {
+		id, err = tx.createTag(name, color, parentId)
+		return err
+	})
+	return
+}
+
+func (db *Database) createTag(name string, color string, parentId uint64) (uint64, error) {
+	if parentId != 0 {
+		query := "SELECT id FROM tags WHERE id = ?;"
+		if err := db.db.QueryRow(query, parentId).Scan(&parentId); err != nil {
+			return 0, fmt.Errorf("Unable to find parent tag %d: %s", parentId, err.Error())
+		}
+	}
+
+	// Postgres uses up an id on a failed insert, so the duplicates are caught
+	// before they get there
+	var count int
+	query := "SELECT COUNT(*) FROM tags WHERE name = ?;"
+	if err := db.db.QueryRow(query, name).Scan(&count); err != nil {
+		return 0, fmt.Errorf("Unable to look up tag %s: %s", name, err)
+	}
+	if count != 0 {
+		return 0, fmt.Errorf("Unable to insert tag: %s already exists", name)
+	}
+
+	query = "INSERT INTO tags (name, color, parentId) VALUES (?, ?, ?);"
+	_, err := db.db.Exec(query, name, color, parentId)
 	if err != nil {
-		if strings.Contains(err.Error(), "UNIQUE constraint") {
+		if db.db.dialect.isUniqueViolation(err) {
 			return 0, fmt.Errorf("Unable to insert tag: %s already exists", name)
 		}
 		return 0, fmt.Errorf("Unable to insert tag: %s", err.Error())
@@ -627,274 +1705,1309 @@ func (db *Database) CreateTag(name string, color string) (uint64, error) {
 	if err := row.Scan(&id); err != nil {
 		return 0, err
 	}
-	return id, nil
+	return id, nil
+}
+
+func (db *Database) DeleteTag(id uint64) (projectIds []uint64, err error) {
+	err = db.transaction(func(tx *Database) error {
+		projectIds, err = tx.deleteTag(id)
+		return err
+	})
+	return
+}
+
+func (db *Database) deleteTag(id uint64) ([]uint64, error) {
+	projIds, err := db.GetProjectIdsByTagId(id)
+	if err != nil {
+		return []uint64{}, fmt.Errorf("Unable to get projects associated with tag: %s", err)
+	}
+
+	// Projects with tagged tasks need to be refreshed as well
+	query := "SELECT DISTINCT tasks.projectId FROM taskTags " +
+		"JOIN tasks ON tasks.id = taskTags.taskId WHERE taskTags.tagId = ? " +
+		"ORDER BY tasks.projectId;"
+	taskProjIds, err := db.getIdsById(query, id)
+	if err != nil {
+		return []uint64{}, fmt.Errorf("Unable to get tasks associated with tag: %s", err)
+	}
+	for _, projId := range taskProjIds {
+		if !inList(projId, projIds) {
+			projIds = append(projIds, projId)
+		}
+	}
+
+	_, err = db.db.Exec("DELETE FROM projectTags WHERE tagId = ?;", id)
+	if err != nil {
+		return []uint64{},
+			fmt.Errorf("Unable to disassociate projects from tag %d: %s", id, err)
+	}
+
+	_, err = db.db.Exec("DELETE FROM taskTags WHERE tagId = ?;", id)
+	if err != nil {
+		return []uint64{},
+			fmt.Errorf("Unable to disassociate tasks from tag %d: %s", id, err)
+	}
+
+	_, err = db.db.Exec("DELETE FROM goals WHERE tagId = ?;", id)
+	if err != nil {
+		return []uint64{}, fmt.Errorf("Unable to delete the goals of tag %d: %s", id, err)
+	}
+
+	// The children of the tag are handed over to its parent
+	query = "UPDATE tags SET parentId = (SELECT parentId FROM tags WHERE id = ?) " +
+		"WHERE parentId = ?;"
+	_, err = db.db.Exec(query, id, id)
+	if err != nil {
+		return []uint64{}, fmt.Errorf("Unable to reparent the children of tag %d: %s", id, err)
+	}
+
+	_, err = db.db.Exec("DELETE FROM tags WHERE id=?;", id)
+	if err != nil {
+		return []uint64{}, fmt.Errorf("Unable to delete tag: %s", err.Error())
+	}
+
+	return projIds, nil
+}
+
+func (db *Database) EditTag(id uint64, newName, newColor string) error {
+	return db.transaction(func(tx *Database) error {
+		return tx.editTag(id, newName, newColor)
+	})
+}
+
+func (db *Database) editTag(id uint64, newName, newColor string) error {
+	_, err := db.db.Exec("UPDATE tags SET name=?, color=? WHERE id=?;", newName, newColor, id)
+	if err != nil {
+		if db.db.dialect.isUniqueViolation(err) {
+			return fmt.Errorf("Unable to rename tag: %s already exists", newName)
+		}
+		return fmt.Errorf("Unable to edit tag: %s", err.Error())
+	}
+	return nil
+}
+
+// Move the tag under a new parent, or to the top level if the parent id is
+// zero; returns the id of the previous parent
+func (db *Database) MoveTag(id, parentId uint64) (oldParentId uint64, err error) {
+	err = db.transaction(func(tx *Database) error {
+		oldParentId, err = tx.moveTag(id, parentId)
+		return err
+	})
+	return
+}
+
+func (db *Database) moveTag(id, parentId uint64) (uint64, error) {
+	query := "SELECT parentId FROM tags WHERE id = ?;"
+	var oldParentId uint64
+	if err := db.db.QueryRow(query, id).Scan(&oldParentId); err != nil {
+		return 0, fmt.Errorf("Unable to find tag %d: %s", id, err.Error())
+	}
+
+	if parentId != 0 {
+		query = "SELECT id FROM tags WHERE id = ?;"
+		if err := db.db.QueryRow(query, parentId).Scan(&parentId); err != nil {
+			return 0, fmt.Errorf("Unable to find parent tag %d: %s", parentId, err.Error())
+		}
+
+		subtree, err := db.GetTagSubtreeIds(id)
+		if err != nil {
+			return 0, fmt.Errorf("Unable to get descendants of tag %d: %s", id, err.Error())
+		}
+
+		if inList(parentId, subtree) {
+			return 0, fmt.Errorf("Unable to move tag: tag %d is a descendant of tag %d",
+				parentId, id)
+		}
+	}
+
+	query = "UPDATE tags SET parentId=? WHERE id=?;"
+	if _, err := db.db.Exec(query, parentId, id); err != nil {
+		return 0, fmt.Errorf("Unable to move tag: %s", err.Error())
+	}
+	return oldParentId, nil
+}
+
+func (db *Database) CreateProject(title, description string, tags []uint64,
+	parentId uint64) (id uint64, err error) {
+	err = db.transaction(func(tx *Database) error {
+		id, err = tx.createProject(title, description, tags, parentId)
+		return err
+	})
+	return
+}
+
+func (db *Database) createProject(title, description string, tags []uint64,
+	parentId uint64) (uint64, error) {
+
+	if parentId != 0 {
+		query := "SELECT id FROM projects WHERE id = ?;"
+		if err := db.db.QueryRow(query, parentId).Scan(&parentId); err != nil {
+			return 0, fmt.Errorf("Unable to find parent project %d: %s", parentId, err.Error())
+		}
+	}
+
+	var count int
+	query := "SELECT COUNT(*) FROM projects WHERE title = ?;"
+	if err := db.db.QueryRow(query, title).Scan(&count); err != nil {
+		return 0, fmt.Errorf("Unable to look up project %s: %s", title, err)
+	}
+	if count != 0 {
+		return 0, fmt.Errorf("Unable to create project: %s already exists", title)
+	}
+
+	query = "INSERT INTO projects (title, description, parentId) VALUES (?, ?, ?)"
+	_, err := db.db.Exec(query, title, description, parentId)
+	if err != nil {
+		if db.db.dialect.isUniqueViolation(err) {
+			return 0, fmt.Errorf("Unable to create project: %s already exists", title)
+		}
+		return 0, fmt.Errorf("Unable to create project: %s", err.Error())
+	}
+
+	query = "SELECT id FROM projects WHERE title = ?"
+	var id uint64
+	err = db.db.QueryRow(query, title).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("Unable to query new project id: %s", err)
+	}
+
+	query = "INSERT INTO projectStatusHistory (projectId, status, timestamp) VALUES (?, ?, ?);"
+	_, err = db.db.Exec(query, id, ProjectActive, time.Now().Unix())
+	if err != nil {
+		return 0, fmt.Errorf("Unable to record the status of project %d: %s", id, err)
+	}
+
+	// Associate new tags
+	query = "INSERT INTO projectTags (projectId, tagId) " +
+		"VALUES (?, ?) ON CONFLICT DO NOTHING;"
+	for _, tagId := range tags {
+		_, err := db.db.Exec(query, id, tagId)
+		if err != nil {
+			return 0, fmt.Errorf("Unable to associate tag with project: (%d %d): %s",
+				id, tagId, err)
+		}
+	}
+	return id, nil
+}
+
+func (db *Database) DeleteProject(id uint64) (tagIds []uint64, err error) {
+	err = db.transaction(func(tx *Database) error {
+		tagIds, err = tx.deleteProject(id)
+		return err
+	})
+	return
+}
+
+func (db *Database) deleteProject(id uint64) ([]uint64, error) {
+	tags, err := db.GetTagIdsByProjectId(id)
+	if err != nil {
+		return []uint64{}, fmt.Errorf("Unable to get tag list: %s", err.Error())
+	}
+
+	_, err = db.db.Exec("DELETE FROM projectTags WHERE projectId = ?;", id)
+	if err != nil {
+		return []uint64{},
+			fmt.Errorf("Unable to disassociate tags from project %d: %s", id, err)
+	}
+
+	_, err = db.db.Exec("DELETE FROM projectStatusHistory WHERE projectId = ?;", id)
+	if err != nil {
+		return []uint64{}, fmt.Errorf("Unable to delete the status history of project %d: %s", id, err)
+	}
+
+	_, err = db.db.Exec("DELETE FROM goals WHERE projectId = ?;", id)
+	if err != nil {
+		return []uint64{}, fmt.Errorf("Unable to delete the goals of project %d: %s", id, err)
+	}
+
+	_, err = db.db.Exec("DELETE FROM milestones WHERE projectId = ?;", id)
+	if err != nil {
+		return []uint64{}, fmt.Errorf("Unable to delete the milestones of project %d: %s", id, err)
+	}
+
+	_, err = db.db.Exec("DELETE FROM timers WHERE projectId = ?;", id)
+	if err != nil {
+		return []uint64{}, fmt.Errorf("Unable to delete the timer of project %d: %s", id, err)
+	}
+
+	// The tasks and the sessions go away with the project, so that nothing
+	// refers to it or stays blocked by its tasks
+	query := "DELETE FROM taskTags WHERE taskId IN (SELECT id FROM tasks WHERE projectId = ?);"
+	if _, err := db.db.Exec(query, id); err != nil {
+		return []uint64{}, fmt.Errorf("Unable to disassociate tags from the tasks of project %d: %s",
+			id, err)
+	}
+
+	query = "DELETE FROM taskDependencies " +
+		"WHERE taskId IN (SELECT id FROM tasks WHERE projectId = ?) " +
+		"OR blockerId IN (SELECT id FROM tasks WHERE projectId = ?);"
+	if _, err := db.db.Exec(query, id, id); err != nil {
+		return []uint64{}, fmt.Errorf("Unable to delete the task dependencies of project %d: %s",
+			id, err)
+	}
+
+	_, err = db.db.Exec("DELETE FROM tasks WHERE projectId = ?;", id)
+	if err != nil {
+		return []uint64{}, fmt.Errorf("Unable to delete the tasks of project %d: %s", id, err)
+	}
+
+	_, err = db.db.Exec("DELETE FROM sessions WHERE projectId = ?;", id)
+	if err != nil {
+		return []uint64{}, fmt.Errorf("Unable to delete the sessions of project %d: %s", id, err)
+	}
+
+	// The sub-projects are handed over to the parent of the project
+	query = "UPDATE projects SET parentId = (SELECT parentId FROM projects WHERE id = ?) " +
+		"WHERE parentId = ?;"
+	_, err = db.db.Exec(query, id, id)
+	if err != nil {
+		return []uint64{},
+			fmt.Errorf("Unable to reparent the sub-projects of project %d: %s", id, err)
+	}
+
+	_, err = db.db.Exec("DELETE FROM projects WHERE id=?;", id)
+	if err != nil {
+		return []uint64{}, fmt.Errorf("Unable to delete project: %s", err.Error())
+	}
+
+	return tags, nil
+}
+
+const (
+	ProjectPlanned  = "planned"
+	ProjectActive   = "active"
+	ProjectPaused   = "paused"
+	ProjectDone     = "done"
+	ProjectArchived = "archived"
+)
+
+var projectStatuses = []string{
+	ProjectPlanned,
+	ProjectActive,
+	ProjectPaused,
+	ProjectDone,
+	ProjectArchived,
+}
+
+func (db *Database) GetProjectStatusHistory(id uint64) ([]StatusChange, error) {
+	history := []StatusChange{}
+	query := "SELECT status, timestamp FROM projectStatusHistory " +
+		"WHERE projectId = ? ORDER BY timestamp, id;"
+	rows, err := db.db.Query(query, id)
+	if err != nil {
+		return []StatusChange{}, fmt.Errorf("Cannot query status history: %s", err.Error())
+	}
+	for rows.Next() {
+		var change StatusChange
+		if err := rows.Scan(&change.Status, &change.Date); err != nil {
+			return []StatusChange{}, fmt.Errorf("Cannot scan status history: %s", err.Error())
+		}
+		history = append(history, change)
+	}
+	if err := rows.Err(); err != nil {
+		return []StatusChange{}, fmt.Errorf("Cannot process status history: %s", err.Error())
+	}
+	return history, nil
+}
+
+func (db *Database) SetProjectStatus(id uint64, status string) error {
+	return db.transaction(func(tx *Database) error {
+		return tx.setProjectStatus(id, status)
+	})
+}
+
+func (db *Database) setProjectStatus(id uint64, status string) error {
+	valid := false
+	for _, s := range projectStatuses {
+		if s == status {
+			valid = true
+		}
+	}
+	if !valid {
+		return fmt.Errorf("Unknown project status: %s", status)
+	}
+
+	query := "SELECT status FROM projects WHERE id = ?;"
+	var oldStatus string
+	if err := db.db.QueryRow(query, id).Scan(&oldStatus); err != nil {
+		return fmt.Errorf("Unable to find project %d: %s", id, err.Error())
+	}
+
+	if oldStatus == status {
+		return nil
+	}
+
+	query = "UPDATE projects SET status=? WHERE id=?;"
+	if _, err := db.db.Exec(query, status, id); err != nil {
+		return fmt.Errorf("Unable to set project status: %s", err.Error())
+	}
+
+	query = "INSERT INTO projectStatusHistory (projectId, status, timestamp) VALUES (?, ?, ?);"
+	if _, err := db.db.Exec(query, id, status, time.Now().Unix()); err != nil {
+		return fmt.Errorf("Unable to record the status of project %d: %s", id, err)
+	}
+	return nil
+}
+
+// Move the project under a new parent, or to the top level if the parent id
+// is zero; returns the id of the previous parent
+func (db *Database) MoveProject(id, parentId uint64) (oldParentId uint64, err error) {
+	err = db.transaction(func(tx *Database) error {
+		oldParentId, err = tx.moveProject(id, parentId)
+		return err
+	})
+	return
+}
+
+func (db *Database) moveProject(id, parentId uint64) (uint64, error) {
+	query := "SELECT parentId FROM projects WHERE id = ?;"
+	var oldParentId uint64
+	if err := db.db.QueryRow(query, id).Scan(&oldParentId); err != nil {
+		return 0, fmt.Errorf("Unable to find project %d: %s", id, err.Error())
+	}
+
+	if parentId != 0 {
+		query = "SELECT id FROM projects WHERE id = ?;"
+		if err := db.db.QueryRow(query, parentId).Scan(&parentId); err != nil {
+			return 0, fmt.Errorf("Unable to find parent project %d: %s", parentId, err.Error())
+		}
+
+		subtree, err := db.GetProjectSubtreeIds(id)
+		if err != nil {
+			return 0, fmt.Errorf("Unable to get sub-projects of project %d: %s", id, err.Error())
+		}
+
+		if inList(parentId, subtree) {
+			return 0, fmt.Errorf("Unable to move project: project %d is a sub-project of project %d",
+				parentId, id)
+		}
+	}
+
+	query = "UPDATE projects SET parentId=? WHERE id=?;"
+	if _, err := db.db.Exec(query, parentId, id); err != nil {
+		return 0, fmt.Errorf("Unable to move project: %s", err.Error())
+	}
+	return oldParentId, nil
+}
+
+func inList(element uint64, lst []uint64) bool {
+	for _, elem := range lst {
+		if element == elem {
+			return true
+		}
+	}
+	return false
+}
+
+func getListDifference(old, new []uint64) (added, deleted []uint64) {
+	added = []uint64{}
+	deleted = []uint64{}
+	for _, elem := range old {
+		if !inList(elem, new) {
+			deleted = append(deleted, elem)
+		}
+	}
+
+	for _, elem := range new {
+		if !inList(elem, old) {
+			added = append(added, elem)
+		}
+	}
+	return
+}
+
+func (db *Database) EditProject(
+	id uint64,
+	title, description string,
+	tags []uint64) (tagIds []uint64, err error) {
+	err = db.transaction(func(tx *Database) error {
+		tagIds, err = tx.editProject(id, title, description, tags)
+		return err
+	})
+	return
+}
+
+func (db *Database) editProject(
+	id uint64,
+	title, description string,
+	tags []uint64) ([]uint64, error) {
+
+	// Update the title and description
+	query := "UPDATE projects SET title=?, description=? WHERE id=?;"
+	_, err := db.db.Exec(query, title, description, id)
+	if err != nil {
+		if db.db.dialect.isUniqueViolation(err) {
+			return []uint64{}, fmt.Errorf("Unable to rename project: %s already exists", title)
+		}
+		return []uint64{}, fmt.Errorf("Unable to rename project: %s", err.Error())
+	}
+
+	// Get the difference of the tag lists
+	currentTags, err := db.GetTagIdsByProjectId(id)
+	if err != nil {
+		return []uint64{}, fmt.Errorf("Unable to get tag list: %s", err.Error())
+	}
+
+	newTags, removedTags := getListDifference(currentTags, tags)
+
+	// Associate new tags
+	query = "INSERT INTO projectTags (projectId, tagId) " +
+		"VALUES (?, ?) ON CONFLICT DO NOTHING;"
+	for _, tagId := range newTags {
+		_, err := db.db.Exec(query, id, tagId)
+		if err != nil {
+			return []uint64{}, fmt.Errorf("Unable to associate tag with project: (%d %d): %s",
+				id, tagId, err)
+		}
+	}
+
+	// Remove the association of old tags
+	query = "DELETE FROM projectTags WHERE projectId = ? AND tagId = ?;"
+	for _, tagId := range removedTags {
+		_, err := db.db.Exec(query, id, tagId)
+		if err != nil {
+			return []uint64{}, fmt.Errorf("Unable to disassociate tag from project: (%d %d): %s",
+				id, tagId, err)
+		}
+	}
+
+	return append(removedTags, newTags...), nil
+}
+
+func checkTaskDates(startDate, dueDate uint64) error {
+	if startDate != 0 && dueDate != 0 && startDate > dueDate {
+		return fmt.Errorf("The start date of a task cannot be later than its due date")
+	}
+	return nil
+}
+
+func (db *Database) AddTask(projectId, parentId uint64, details TaskDetails) error {
+	return db.transaction(func(tx *Database) error {
+		_, err := tx.addTask(projectId, parentId, details)
+		return err
+	})
+}
+
+func (db *Database) addTask(projectId, parentId uint64, details TaskDetails) (uint64, error) {
+	if err := checkTaskDates(details.StartDate, details.DueDate); err != nil {
+		return 0, err
+	}
+
+	if err := checkRecurrence(details.Recurrence, details.RecurrenceInterval); err != nil {
+		return 0, err
+	}
+
+	if parentId != 0 {
+		query := "SELECT projectId FROM tasks WHERE id = ?"
+		var parentProjectId uint64
+		if err := db.db.QueryRow(query, parentId).Scan(&parentProjectId); err != nil {
+			return 0, fmt.Errorf("Unable to find parent task %d: %s", parentId, err.Error())
+		}
+		if parentProjectId != projectId {
+			return 0, fmt.Errorf("Parent task %d belongs to a different project", parentId)
+		}
+	}
+
+	query := "INSERT INTO tasks (projectId, parentId, done, priority, title, description, " +
+		"startDate, dueDate, recurrence, recurrenceInterval, estimate, position) " +
+		"VALUES (?, ?, FALSE, ?, ?, ?, ?, ?, ?, ?, ?, " +
+		"(SELECT COALESCE(MAX(position), 0) + 1 FROM tasks WHERE projectId = ?));"
+	id, err := db.db.dialect.insert(db.db, query, projectId, parentId, details.Priority,
+		details.Title, details.Description, details.StartDate, details.DueDate,
+		details.Recurrence, details.RecurrenceInterval, details.Estimate, projectId)
+	if err != nil {
+		return 0, fmt.Errorf("Unable add new task: %s", err.Error())
+	}
+	return id, nil
+}
+
+// Delete the task together with all of its subtasks
+func (db *Database) DeleteTask(id uint64) (projectId uint64, err error) {
+	err = db.transaction(func(tx *Database) error {
+		projectId, err = tx.deleteTask(id)
+		return err
+	})
+	return
+}
+
+func (db *Database) deleteTask(id uint64) (uint64, error) {
+	query := "SELECT projectId FROM tasks WHERE id = ?"
+	var projectId uint64
+	if err := db.db.QueryRow(query, id).Scan(&projectId); err != nil {
+		return 0, err
+	}
+
+	ids, err := db.GetSubtaskIds(id)
+	if err != nil {
+		return 0, fmt.Errorf("Unable to get subtasks of task %d: %s", id, err.Error())
+	}
+
+	for _, taskId := range ids {
+		// The logged time stays with the project
+		query = "UPDATE sessions SET taskId = 0 WHERE taskId = ?"
+		if _, err := db.db.Exec(query, taskId); err != nil {
+			return 0, fmt.Errorf("Unable to detach sessions from task: %s", err.Error())
+		}
+
+		query = "DELETE FROM taskTags WHERE taskId = ?"
+		if _, err := db.db.Exec(query, taskId); err != nil {
+			return 0, fmt.Errorf("Unable to disassociate tags from task: %s", err.Error())
+		}
+
+		query = "DELETE FROM taskDependencies WHERE taskId = ? OR blockerId = ?"
+		if _, err := db.db.Exec(query, taskId, taskId); err != nil {
+			return 0, fmt.Errorf("Unable to delete task dependencies: %s", err.Error())
+		}
+
+		query = "DELETE FROM tasks WHERE id = ?"
+		if _, err := db.db.Exec(query, taskId); err != nil {
+			return 0, fmt.Errorf("Unable to delete task: %s", err.Error())
+		}
+	}
+	return projectId, nil
+}
+
+// Toggle the status of the task; if cascade is set, all the subtasks are
+// given the same status as their ancestor
+func (db *Database) ToggleTask(id uint64, cascade bool) (projectId uint64, err error) {
+	err = db.transaction(func(tx *Database) error {
+		projectId, err = tx.toggleTask(id, cascade)
+		return err
+	})
+	return
+}
+
+func (db *Database) toggleTask(id uint64, cascade bool) (uint64, error) {
+	query := "SELECT projectId FROM tasks WHERE id = ?"
+	var projectId uint64
+	if err := db.db.QueryRow(query, id).Scan(&projectId); err != nil {
+		return 0, fmt.Errorf("Unable to get project id for task %s", err.Error())
+	}
+
+	query = "SELECT done FROM tasks WHERE id = ?"
+	var status bool
+	err := db.db.QueryRow(query, id).Scan(&status)
+	if err != nil {
+		return 0, fmt.Errorf("Unable get task status: %s", err.Error())
+	}
+
+	ids := []uint64{id}
+	if cascade {
+		if ids, err = db.GetSubtaskIds(id); err != nil {
+			return 0, fmt.Errorf("Unable to get subtasks of task %d: %s", id, err.Error())
+		}
+	}
+
+	query = "UPDATE tasks SET done=? WHERE id=?"
+	for _, taskId := range ids {
+		_, err = db.db.Exec(query, !status, taskId)
+		if err != nil {
+			return 0, fmt.Errorf("Unable toggle task: %s", err.Error())
+		}
+	}
+
+	// Only the toggled task is scheduled again; the subtasks finished together
+	// with it would otherwise reappear under a finished parent
+	if !status {
+		if err := db.scheduleNextOccurrence(id); err != nil {
+			return 0, err
+		}
+	}
+
+	return projectId, nil
+}
+
+// If the task is recurring, create its next occurrence and hand the
+// recurrence rule over to it, so that re-toggling the finished instance
+// does not schedule the task again
+func (db *Database) scheduleNextOccurrence(id uint64) error {
+	query := "SELECT " + db.taskColumns() + " FROM tasks WHERE id = ?;"
+	tasks, err := db.queryTasks(query, id)
+	if err != nil {
+		return fmt.Errorf("Unable to get task %d: %s", id, err.Error())
+	}
+	if len(tasks) != 1 || tasks[0].Recurrence == "" {
+		return nil
+	}
+
+	err = db.copyOccurrence(tasks[0], tasks[0].ParentId, occurrenceShift(tasks[0]))
+	if err != nil {
+		return fmt.Errorf("Unable to schedule the next occurrence of task %d: %s", id, err)
+	}
+	return nil
+}
+
+// Copy the task, its tags, its milestone and its subtasks into the next
+// occurrence under the given parent
+func (db *Database) copyOccurrence(task Task, parentId, shift uint64) error {
+	id, err := db.addTask(task.ProjectId, parentId, occurrenceDetails(task, shift))
+	if err != nil {
+		return err
+	}
+
+	query := "UPDATE tasks SET milestoneId = ? WHERE id = ?;"
+	if _, err := db.db.Exec(query, task.MilestoneId, id); err != nil {
+		return fmt.Errorf("Unable to assign the milestone: %s", err)
+	}
+
+	query = "INSERT INTO taskTags (taskId, tagId) SELECT ?, tagId FROM taskTags WHERE taskId = ?;"
+	if _, err := db.db.Exec(query, id, task.Id); err != nil {
+		return fmt.Errorf("Unable to copy the tags: %s", err)
+	}
+
+	query = "UPDATE tasks SET recurrence='' WHERE id=?"
+	if _, err := db.db.Exec(query, task.Id); err != nil {
+		return fmt.Errorf("Unable to clear the recurrence of task %d: %s", task.Id, err)
+	}
+
+	query = "SELECT " + db.taskColumns() + " FROM tasks WHERE parentId = ? ORDER BY position;"
+	subtasks, err := db.queryTasks(query, task.Id)
+	if err != nil {
+		return fmt.Errorf("Unable to get subtasks of task %d: %s", task.Id, err)
+	}
+	for _, subtask := range subtasks {
+		if err := db.copyOccurrence(subtask, id, shift); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// The shift of the dates of a recurring task to its next occurrence; it is
+// as many recurrence periods as it takes for the occurrence to be in the
+// future
+func occurrenceShift(task Task) uint64 {
+	now := time.Now()
+	base := now
+	if task.DueDate != 0 {
+		base = time.Unix(int64(task.DueDate), 0)
+	} else if task.StartDate != 0 {
+		base = time.Unix(int64(task.StartDate), 0)
+	}
+	next := nextOccurrence(base, task.Recurrence, task.RecurrenceInterval, 1)
+	for n := uint64(2); !next.After(now) && next.After(base); n++ {
+		next = nextOccurrence(base, task.Recurrence, task.RecurrenceInterval, n)
+	}
+	return uint64(next.Unix() - base.Unix())
+}
+
+// The details of the task in an occurrence with the dates shifted
+func occurrenceDetails(task Task, shift uint64) TaskDetails {
+	details := TaskDetails{
+		Title:              task.Title,
+		Description:        task.Description,
+		Priority:           uint64(task.Priority),
+		Recurrence:         task.Recurrence,
+		RecurrenceInterval: task.RecurrenceInterval,
+		Estimate:           task.Estimate,
+	}
+	if task.StartDate != 0 {
+		details.StartDate = task.StartDate + shift
+	}
+	if task.DueDate != 0 {
+		details.DueDate = task.DueDate + shift
+	}
+	return details
+}
+
+func (db *Database) EditTask(id uint64, details TaskDetails) (projectId uint64, err error) {
+	err = db.transaction(func(tx *Database) error {
+		projectId, err = tx.editTask(id, details)
+		return err
+	})
+	return
+}
+
+func (db *Database) editTask(id uint64, details TaskDetails) (uint64, error) {
+	if err := checkTaskDates(details.StartDate, details.DueDate); err != nil {
+		return 0, err
+	}
+
+	if err := checkRecurrence(details.Recurrence, details.RecurrenceInterval); err != nil {
+		return 0, err
+	}
+
+	query := "SELECT projectId FROM tasks WHERE id = ?"
+	var projectId uint64
+	if err := db.db.QueryRow(query, id).Scan(&projectId); err != nil {
+		return 0, fmt.Errorf("Unable get projectId for task: %s", err.Error())
+	}
+
+	query = "UPDATE tasks SET title=?, description=?, priority=?, startDate=?, dueDate=?, " +
+		"recurrence=?, recurrenceInterval=?, estimate=? WHERE id=?"
+	_, err := db.db.Exec(query, details.Title, details.Description, details.Priority,
+		details.StartDate, details.DueDate, details.Recurrence, details.RecurrenceInterval,
+		details.Estimate, id)
+	if err != nil {
+		return 0, fmt.Errorf("Unable set task description: %s", err.Error())
+	}
+
+	return projectId, nil
+}
+
+// Move the task just before or just after the target task; all the positions
+// within the project are rewritten
+func (db *Database) ReorderTask(id, targetId uint64, after bool) (projectId uint64, err error) {
+	err = db.transaction(func(tx *Database) error {
+		projectId, err = tx.reorderTask(id, targetId, after)
+		return err
+	})
+	return
+}
+
+func (db *Database) reorderTask(id, targetId uint64, after bool) (uint64, error) {
+	query := "SELECT projectId FROM tasks WHERE id = ?"
+	var projectId uint64
+	if err := db.db.QueryRow(query, id).Scan(&projectId); err != nil {
+		return 0, fmt.Errorf("Unable to find task %d: %s", id, err.Error())
+	}
+
+	var targetProjectId uint64
+	if err := db.db.QueryRow(query, targetId).Scan(&targetProjectId); err != nil {
+		return 0, fmt.Errorf("Unable to find task %d: %s", targetId, err.Error())
+	}
+
+	if projectId != targetProjectId {
+		return 0, fmt.Errorf("Cannot reorder tasks belonging to different projects")
+	}
+
+	if id == targetId {
+		return projectId, nil
+	}
+
+	ids := []uint64{}
+	query = "SELECT id FROM tasks WHERE projectId = ? ORDER BY position, id;"
+	rows, err := db.db.Query(query, projectId)
+	if err != nil {
+		return 0, fmt.Errorf("Unable to query task order: %s", err.Error())
+	}
+	for rows.Next() {
+		var taskId uint64
+		if err := rows.Scan(&taskId); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("Unable to scan task order: %s", err.Error())
+		}
+		if taskId != id {
+			ids = append(ids, taskId)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("Unable to process task order: %s", err.Error())
+	}
+
+	order := make([]uint64, 0, len(ids)+1)
+	for _, taskId := range ids {
+		if taskId == targetId && !after {
+			order = append(order, id)
+		}
+		order = append(order, taskId)
+		if taskId == targetId && after {
+			order = append(order, id)
+		}
+	}
+
+	query = "UPDATE tasks SET position=? WHERE id=?"
+	for position, taskId := range order {
+		if _, err := db.db.Exec(query, position+1, taskId); err != nil {
+			return 0, fmt.Errorf("Unable to reorder tasks: %s", err.Error())
+		}
+	}
+	return projectId, nil
+}
+
+// Get the ids of the tags associated with the task or any of its subtasks
+func (db *Database) GetSubtaskTagIds(id uint64) ([]uint64, error) {
+	query := "WITH RECURSIVE subtree(id) AS (" +
+		"SELECT CAST(? AS BIGINT) UNION ALL " +
+		"SELECT tasks.id FROM tasks JOIN subtree ON tasks.parentId = subtree.id) " +
+		"SELECT DISTINCT tagId FROM taskTags WHERE taskId IN (SELECT id FROM subtree);"
+	return db.getIdsById(query, id)
+}
+
+func (db *Database) AddTaskTag(taskId, tagId uint64) (projectId uint64, err error) {
+	err = db.transaction(func(tx *Database) error {
+		projectId, err = tx.addTaskTag(taskId, tagId)
+		return err
+	})
+	return
 }
 
-func (db *Database) DeleteTag(id uint64) ([]uint64, error) {
-	if id == 2 {
-		return []uint64{}, fmt.Errorf("Cannot delete 'Archived'")
+func (db *Database) addTaskTag(taskId, tagId uint64) (uint64, error) {
+	query := "SELECT projectId FROM tasks WHERE id = ?"
+	var projectId uint64
+	if err := db.db.QueryRow(query, taskId).Scan(&projectId); err != nil {
+		return 0, fmt.Errorf("Unable to find task %d: %s", taskId, err.Error())
 	}
 
-	if id == 1 {
-		return []uint64{}, fmt.Errorf("Cannot delete 'Limbo'")
+	query = "SELECT id FROM tags WHERE id = ?"
+	if err := db.db.QueryRow(query, tagId).Scan(&tagId); err != nil {
+		return 0, fmt.Errorf("Unable to find tag %d: %s", tagId, err.Error())
 	}
 
-	projIds, err := db.GetProjectIdsByTagId(id)
-	if err != nil {
-		return []uint64{}, fmt.Errorf("Unable to get projects associated with tag: %s", err)
+	query = "INSERT INTO taskTags (taskId, tagId) " +
+		"VALUES (?, ?) ON CONFLICT DO NOTHING;"
+	if _, err := db.db.Exec(query, taskId, tagId); err != nil {
+		return 0, fmt.Errorf("Unable to associate tag with task: (%d %d): %s",
+			taskId, tagId, err)
 	}
+	return projectId, nil
+}
 
-	_, err = db.db.Exec("DELETE FROM projectTags WHERE tagId = ?;", id)
-	if err != nil {
-		return []uint64{},
-			fmt.Errorf("Unable to disassociate projects from tag %d: %s", id, err)
+func (db *Database) RemoveTaskTag(taskId, tagId uint64) (projectId uint64, err error) {
+	err = db.transaction(func(tx *Database) error {
+		projectId, err = tx.removeTaskTag(taskId, tagId)
+		return err
+	})
+	return
+}
+
+func (db *Database) removeTaskTag(taskId, tagId uint64) (uint64, error) {
+	query := "SELECT projectId FROM tasks WHERE id = ?"
+	var projectId uint64
+	if err := db.db.QueryRow(query, taskId).Scan(&projectId); err != nil {
+		return 0, fmt.Errorf("Unable to find task %d: %s", taskId, err.Error())
 	}
 
-	_, err = db.db.Exec("DELETE FROM tags WHERE id=?;", id)
-	if err != nil {
-		return []uint64{}, fmt.Errorf("Unable to delete tag: %s", err.Error())
+	query = "DELETE FROM taskTags WHERE taskId = ? AND tagId = ?;"
+	if _, err := db.db.Exec(query, taskId, tagId); err != nil {
+		return 0, fmt.Errorf("Unable to disassociate tag from task: (%d %d): %s",
+			taskId, tagId, err)
 	}
+	return projectId, nil
+}
 
-	return projIds, nil
+// Mark the task as blocked by another task; returns the id of the project
+// of the blocked task
+func (db *Database) LinkTasks(taskId, blockerId uint64) (projectId uint64, err error) {
+	err = db.transaction(func(tx *Database) error {
+		projectId, err = tx.linkTasks(taskId, blockerId)
+		return err
+	})
+	return
 }
 
-func (db *Database) EditTag(id uint64, newName, newColor string) error {
-	if id == 2 {
-		return fmt.Errorf("Cannot edit 'Archived'")
+func (db *Database) linkTasks(taskId, blockerId uint64) (uint64, error) {
+	if taskId == blockerId {
+		return 0, fmt.Errorf("A task cannot block itself")
 	}
 
-	if id == 1 {
-		return fmt.Errorf("Cannot edit 'Limbo'")
+	query := "SELECT projectId FROM tasks WHERE id = ?"
+	var projectId uint64
+	if err := db.db.QueryRow(query, taskId).Scan(&projectId); err != nil {
+		return 0, fmt.Errorf("Unable to find task %d: %s", taskId, err.Error())
 	}
 
-	_, err := db.db.Exec("UPDATE tags SET name=?, color=? WHERE id=?;", newName, newColor, id)
-	if err != nil {
-		if strings.Contains(err.Error(), "UNIQUE constraint") {
-			return fmt.Errorf("Unable to rename tag: %s already exists", newName)
-		}
-		return fmt.Errorf("Unable to edit tag: %s", err.Error())
+	var blockerProjectId uint64
+	if err := db.db.QueryRow(query, blockerId).Scan(&blockerProjectId); err != nil {
+		return 0, fmt.Errorf("Unable to find task %d: %s", blockerId, err.Error())
 	}
-	return nil
-}
 
-func (db *Database) CreateProject(title, description string, tags []uint64) (uint64, error) {
-	query := "INSERT INTO projects (title, description) VALUES (?, ?)"
-	_, err := db.db.Exec(query, title, description)
-	if err != nil {
-		if strings.Contains(err.Error(), "UNIQUE constraint") {
-			return 0, fmt.Errorf("Unable to create project: %s already exists", title)
-		}
-		return 0, fmt.Errorf("Unable to create project: %s", err.Error())
+	// The new link closes a cycle if the task already blocks the blocker,
+	// directly or indirectly
+	query = "WITH RECURSIVE blockers(id) AS (" +
+		"SELECT blockerId FROM taskDependencies WHERE taskId = ? UNION " +
+		"SELECT taskDependencies.blockerId FROM taskDependencies " +
+		"JOIN blockers ON taskDependencies.taskId = blockers.id) " +
+		"SELECT COUNT(*) FROM blockers WHERE id = ?;"
+	var count uint64
+	if err := db.db.QueryRow(query, blockerId, taskId).Scan(&count); err != nil {
+		return 0, fmt.Errorf("Unable to check task dependencies: %s", err.Error())
 	}
-
-	query = "SELECT id FROM projects WHERE title = ?"
-	var id uint64
-	err = db.db.QueryRow(query, title).Scan(&id)
-	if err != nil {
-		return 0, fmt.Errorf("Unable to query new project id: %s", err)
+	if count != 0 {
+		return 0, fmt.Errorf("Unable to link tasks: task %d already depends on task %d",
+			blockerId, taskId)
 	}
 
-	// Associate new tags
-	query = "INSERT OR IGNORE INTO projectTags (projectId, tagId) VALUES (?, ?);"
-	for _, tagId := range tags {
-		_, err := db.db.Exec(query, id, tagId)
-		if err != nil {
-			return 0, fmt.Errorf("Unable to associate tag with project: (%d %d): %s",
-				id, tagId, err)
-		}
+	query = "INSERT INTO taskDependencies (taskId, blockerId) " +
+		"VALUES (?, ?) ON CONFLICT DO NOTHING;"
+	if _, err := db.db.Exec(query, taskId, blockerId); err != nil {
+		return 0, fmt.Errorf("Unable to link tasks: %s", err.Error())
 	}
-	return id, nil
+	return projectId, nil
 }
 
-func (db *Database) DeleteProject(id uint64) ([]uint64, error) {
-	tags, err := db.GetTagIdsByProjectId(id)
-	if err != nil {
-		return []uint64{}, fmt.Errorf("Unable to get tag list: %s", err.Error())
-	}
+func (db *Database) UnlinkTasks(taskId, blockerId uint64) (projectId uint64, err error) {
+	err = db.transaction(func(tx *Database) error {
+		projectId, err = tx.unlinkTasks(taskId, blockerId)
+		return err
+	})
+	return
+}
 
-	_, err = db.db.Exec("DELETE FROM projectTags WHERE projectId = ?;", id)
-	if err != nil {
-		return []uint64{},
-			fmt.Errorf("Unable to disassociate tags from project %d: %s", id, err)
+func (db *Database) unlinkTasks(taskId, blockerId uint64) (uint64, error) {
+	query := "SELECT projectId FROM tasks WHERE id = ?"
+	var projectId uint64
+	if err := db.db.QueryRow(query, taskId).Scan(&projectId); err != nil {
+		return 0, fmt.Errorf("Unable to find task %d: %s", taskId, err.Error())
 	}
 
-	_, err = db.db.Exec("DELETE FROM projects WHERE id=?;", id)
-	if err != nil {
-		return []uint64{}, fmt.Errorf("Unable to delete project: %s", err.Error())
+	query = "DELETE FROM taskDependencies WHERE taskId = ? AND blockerId = ?;"
+	if _, err := db.db.Exec(query, taskId, blockerId); err != nil {
+		return 0, fmt.Errorf("Unable to unlink tasks: %s", err.Error())
 	}
+	return projectId, nil
+}
 
-	return tags, nil
+// Get the ids of the projects containing tasks blocked by the given task or
+// any of its subtasks
+func (db *Database) GetDependentProjectIds(id uint64) ([]uint64, error) {
+	query := "WITH RECURSIVE subtree(id) AS (" +
+		"SELECT CAST(? AS BIGINT) UNION ALL " +
+		"SELECT tasks.id FROM tasks JOIN subtree ON tasks.parentId = subtree.id) " +
+		"SELECT DISTINCT tasks.projectId FROM taskDependencies " +
+		"JOIN tasks ON tasks.id = taskDependencies.taskId " +
+		"WHERE taskDependencies.blockerId IN (SELECT id FROM subtree);"
+	return db.getIdsById(query, id)
 }
 
-func inList(element uint64, lst []uint64) bool {
-	for _, elem := range lst {
-		if element == elem {
-			return true
-		}
+func (db *Database) checkSessionTask(projectId, taskId uint64) error {
+	if taskId == 0 {
+		return nil
 	}
-	return false
+
+	query := "SELECT projectId FROM tasks WHERE id = ?"
+	var taskProjectId uint64
+	if err := db.db.QueryRow(query, taskId).Scan(&taskProjectId); err != nil {
+		return fmt.Errorf("Unable to find task %d: %s", taskId, err.Error())
+	}
+	if taskProjectId != projectId {
+		return fmt.Errorf("Task %d belongs to a different project", taskId)
+	}
+	return nil
 }
 
-func getListDifference(old, new []uint64) (added, deleted []uint64) {
-	added = []uint64{}
-	deleted = []uint64{}
-	for _, elem := range old {
-		if !inList(elem, new) {
-			deleted = append(deleted, elem)
-		}
+func (db *Database) AddSession(projectId, taskId, duration, date uint64, note string) error {
+	return db.transaction(func(tx *Database) error {
+		return tx.addSession(projectId, taskId, duration, date, note)
+	})
+}
+
+func (db *Database) addSession(projectId, taskId, duration, date uint64, note string) error {
+	if err := db.checkSessionTask(projectId, taskId); err != nil {
+		return err
 	}
 
-	for _, elem := range new {
-		if !inList(elem, old) {
-			added = append(added, elem)
-		}
+	query := "INSERT INTO sessions (projectId, taskId, timestamp, duration, note) " +
+		"VALUES (?, ?, ?, ?, ?)"
+	_, err := db.db.Exec(query, projectId, taskId, date, duration, note)
+	if err != nil {
+		return fmt.Errorf("Unable to create project: %s", err.Error())
 	}
-	return
+	return nil
 }
 
-func (db *Database) EditProject(
-	id uint64,
-	title, description string,
-	tags []uint64) ([]uint64, error) {
+func (db *Database) EditSession(id, taskId, duration, date uint64,
+	note string) (projectId uint64, err error) {
+	err = db.transaction(func(tx *Database) error {
+		projectId, err = tx.editSession(id, taskId, duration, date, note)
+		return err
+	})
+	return
+}
 
-	// Update the title and description
-	query := "UPDATE projects SET title=?, description=? WHERE id=?;"
-	_, err := db.db.Exec(query, title, description, id)
-	if err != nil {
-		if strings.Contains(err.Error(), "UNIQUE constraint") {
-			return []uint64{}, fmt.Errorf("Unable to rename project: %s already exists", title)
-		}
-		return []uint64{}, fmt.Errorf("Unable to rename project: %s", err.Error())
+func (db *Database) editSession(id, taskId, duration, date uint64, note string) (uint64, error) {
+	query := "SELECT projectId FROM sessions WHERE id = ?"
+	var projectId uint64
+	if err := db.db.QueryRow(query, id).Scan(&projectId); err != nil {
+		return 0, fmt.Errorf("Unable to get project id for session %s", err.Error())
 	}
 
-	// Get the difference of the tag lists
-	currentTags, err := db.GetTagIdsByProjectId(id)
-	if err != nil {
-		return []uint64{}, fmt.Errorf("Unable to get tag list: %s", err.Error())
+	if err := db.checkSessionTask(projectId, taskId); err != nil {
+		return 0, err
 	}
 
-	newTags, removedTags := getListDifference(currentTags, tags)
+	query = "UPDATE sessions SET taskId=?, timestamp=?, duration=?, note=? WHERE id=?"
+	if _, err := db.db.Exec(query, taskId, date, duration, note, id); err != nil {
+		return 0, fmt.Errorf("Unable to edit session: %s", err.Error())
+	}
+	return projectId, nil
+}
 
-	// Associate new tags
-	query = "INSERT OR IGNORE INTO projectTags (projectId, tagId) VALUES (?, ?);"
-	for _, tagId := range newTags {
-		_, err := db.db.Exec(query, id, tagId)
+// Compare the estimated and the logged time of the tasks of a project or of
+// all the projects if the id is zero
+func (db *Database) GetEstimateReport(projectId uint64) ([]ProjectEstimate, error) {
+	reports := []ProjectEstimate{}
+	projectMap := make(map[uint64]int)
+
+	query := "SELECT projects.id, projects.title, " +
+		"COALESCE(SUM(sessions.duration), 0), " +
+		"COALESCE(SUM(CASE WHEN sessions.taskId = 0 THEN sessions.duration END), 0) " +
+		"FROM projects LEFT JOIN sessions ON sessions.projectId = projects.id " +
+		"WHERE ? = 0 OR projects.id = ? GROUP BY projects.id ORDER BY projects.id;"
+	rows, err := db.db.Query(query, projectId, projectId)
+	if err != nil {
+		return []ProjectEstimate{}, fmt.Errorf("Unable to query project time: %s", err)
+	}
+	for rows.Next() {
+		report := ProjectEstimate{Tasks: []TaskEstimate{}}
+		err := rows.Scan(&report.ProjectId, &report.Title, &report.Logged, &report.Unassigned)
 		if err != nil {
-			return []uint64{}, fmt.Errorf("Unable to associate tag with project: (%d %d): %s",
-				id, tagId, err)
+			return []ProjectEstimate{}, fmt.Errorf("Unable to scan project time: %s", err)
 		}
+		projectMap[report.ProjectId] = len(reports)
+		reports = append(reports, report)
+	}
+	if err := rows.Err(); err != nil {
+		return []ProjectEstimate{}, fmt.Errorf("Unable to process project time: %s", err)
 	}
 
-	// Remove the association of old tags
-	query = "DELETE FROM projectTags WHERE projectId = ? AND tagId = ?;"
-	for _, tagId := range removedTags {
-		_, err := db.db.Exec(query, id, tagId)
+	query = "SELECT tasks.id, tasks.projectId, tasks.title, tasks.done, tasks.estimate, " +
+		"COALESCE(SUM(sessions.duration), 0) " +
+		"FROM tasks LEFT JOIN sessions ON sessions.taskId = tasks.id " +
+		"WHERE ? = 0 OR tasks.projectId = ? GROUP BY tasks.id " +
+		"HAVING tasks.estimate != 0 OR COALESCE(SUM(sessions.duration), 0) != 0 " +
+		"ORDER BY tasks.id;"
+	rows, err = db.db.Query(query, projectId, projectId)
+	if err != nil {
+		return []ProjectEstimate{}, fmt.Errorf("Unable to query task time: %s", err)
+	}
+	for rows.Next() {
+		var task TaskEstimate
+		var taskProjectId uint64
+		err := rows.Scan(&task.TaskId, &taskProjectId, &task.Title, &task.Done,
+			&task.Estimate, &task.Logged)
 		if err != nil {
-			return []uint64{}, fmt.Errorf("Unable to disassociate tag from project: (%d %d): %s",
-				id, tagId, err)
+			return []ProjectEstimate{}, fmt.Errorf("Unable to scan task time: %s", err)
+		}
+		idx, ok := projectMap[taskProjectId]
+		if !ok {
+			continue
 		}
+		reports[idx].Estimate += task.Estimate
+		reports[idx].Tasks = append(reports[idx].Tasks, task)
 	}
-
-	return append(removedTags, newTags...), nil
+	if err := rows.Err(); err != nil {
+		return []ProjectEstimate{}, fmt.Errorf("Unable to process task time: %s", err)
+	}
+	return reports, nil
 }
 
-func (db *Database) AddTask(projectId uint64, title, description string, priority uint64) error {
-	query := "INSERT INTO tasks (projectId, done, priority, title, description)" +
-		"VALUES (?, 0, ?, ?, ?);"
-	_, err := db.db.Exec(query, projectId, priority, title, description)
+// Get the time logged in the [from, to) range, in total and per project; the
+// range can also be given as a calendar period offset from the current one
+func (db *Database) GetRangeReport(from, to uint64, period string, offset int) (RangeReport, error) {
+	from, to, err := db.periods.resolveRange(from, to, period, offset)
 	if err != nil {
-		return fmt.Errorf("Unable add new task: %s", err.Error())
+		return RangeReport{}, err
 	}
-	return nil
+
+	report := RangeReport{From: from, To: to, Projects: []ProjectTotal{}}
+	query := "SELECT projects.id, projects.title, SUM(sessions.duration) " +
+		"FROM sessions JOIN projects ON projects.id = sessions.projectId " +
+		"WHERE sessions.timestamp >= ? AND sessions.timestamp < ? " +
+		"GROUP BY projects.id ORDER BY projects.title;"
+	rows, err := db.db.Query(query, from, to)
+	if err != nil {
+		return RangeReport{}, fmt.Errorf("Unable to query range totals: %s", err)
+	}
+	for rows.Next() {
+		var total ProjectTotal
+		if err := rows.Scan(&total.ProjectId, &total.Title, &total.Duration); err != nil {
+			return RangeReport{}, fmt.Errorf("Unable to scan range totals: %s", err)
+		}
+		report.DurationTotal += total.Duration
+		report.Projects = append(report.Projects, total)
+	}
+	if err := rows.Err(); err != nil {
+		return RangeReport{}, fmt.Errorf("Unable to process range totals: %s", err)
+	}
+	return report, nil
 }
 
-func (db *Database) DeleteTask(id uint64) (uint64, error) {
-	query := "SELECT projectId FROM tasks WHERE id = ?"
+func (db *Database) DeleteSession(id uint64) (projectId uint64, err error) {
+	err = db.transaction(func(tx *Database) error {
+		projectId, err = tx.deleteSession(id)
+		return err
+	})
+	return
+}
+
+func (db *Database) deleteSession(id uint64) (uint64, error) {
+	query := "SELECT projectId FROM sessions WHERE id = ?"
 	var projectId uint64
 	if err := db.db.QueryRow(query, id).Scan(&projectId); err != nil {
-		return 0, err
+		return 0, fmt.Errorf("Unable to get project id for session %s", err.Error())
 	}
 
-	query = "DELETE FROM tasks WHERE id = ?"
+	query = "DELETE FROM sessions WHERE id = ?"
 	if _, err := db.db.Exec(query, id); err != nil {
-		return 0, fmt.Errorf("Unable to delete task: %s", err.Error())
+		return 0, fmt.Errorf("Unable to delete session: %s", err.Error())
 	}
 	return projectId, nil
 }
 
-func (db *Database) ToggleTask(id uint64) (uint64, error) {
-	query := "SELECT projectId FROM tasks WHERE id = ?"
-	var projectId uint64
-	if err := db.db.QueryRow(query, id).Scan(&projectId); err != nil {
-		return 0, fmt.Errorf("Unable to get project id for task %s", err.Error())
-	}
-
-	query = "SELECT done FROM tasks WHERE id = ?"
-	var status bool
-	err := db.db.QueryRow(query, id).Scan(&status)
+func (db *Database) GetTimer(projectId uint64) (Timer, error) {
+	query := "SELECT projectId, started, resumed, elapsed, running FROM timers WHERE projectId = ?;"
+	var timer Timer
+	err := db.db.QueryRow(query, projectId).Scan(&timer.ProjectId, &timer.Started,
+		&timer.Resumed, &timer.Elapsed, &timer.Running)
 	if err != nil {
-		return 0, fmt.Errorf("Unable get task status: %s", err.Error())
+		return Timer{}, err
 	}
+	return timer, nil
+}
 
-	query = "UPDATE tasks SET done=? WHERE id=?"
-	_, err = db.db.Exec(query, !status, id)
+func (db *Database) GetTimerList() ([]Timer, error) {
+	timers := []Timer{}
+	query := "SELECT projectId, started, resumed, elapsed, running FROM timers ORDER BY projectId;"
+	rows, err := db.db.Query(query)
 	if err != nil {
-		return 0, fmt.Errorf("Unable toggle task: %s", err.Error())
+		return []Timer{}, fmt.Errorf("Cannot query timers: %s", err.Error())
+	}
+	for rows.Next() {
+		var timer Timer
+		err := rows.Scan(&timer.ProjectId, &timer.Started, &timer.Resumed,
+			&timer.Elapsed, &timer.Running)
+		if err != nil {
+			return []Timer{}, fmt.Errorf("Cannot scan timers: %s", err.Error())
+		}
+		timers = append(timers, timer)
+	}
+	if err := rows.Err(); err != nil {
+		return []Timer{}, fmt.Errorf("Cannot process timers: %s", err.Error())
 	}
+	return timers, nil
+}
 
-	return projectId, nil
+// Start a new timer for the project or resume a paused one
+func (db *Database) StartTimer(projectId uint64) error {
+	return db.transaction(func(tx *Database) error {
+		return tx.startTimer(projectId)
+	})
 }
 
-func (db *Database) EditTask(id uint64, title, description string, priority uint64) (uint64, error) {
-	query := "SELECT projectId FROM tasks WHERE id = ?"
-	var projectId uint64
-	if err := db.db.QueryRow(query, id).Scan(&projectId); err != nil {
-		return 0, fmt.Errorf("Unable get projectId for task: %s", err.Error())
+func (db *Database) startTimer(projectId uint64) error {
+	now := uint64(time.Now().Unix())
+	timer, err := db.GetTimer(projectId)
+	if err == sql.ErrNoRows {
+		query := "INSERT INTO timers (projectId, started, resumed, elapsed, running) " +
+			"VALUES (?, ?, ?, 0, TRUE);"
+		if _, err := db.db.Exec(query, projectId, now, now); err != nil {
+			return fmt.Errorf("Unable to start timer: %s", err.Error())
+		}
+		return nil
 	}
 
-	query = "UPDATE tasks SET title=?, description=?, priority=? WHERE id=?"
-	_, err := db.db.Exec(query, title, description, priority, id)
 	if err != nil {
-		return 0, fmt.Errorf("Unable set task description: %s", err.Error())
+		return fmt.Errorf("Unable to get timer for project %d: %s", projectId, err.Error())
 	}
 
-	return projectId, nil
+	if timer.Running {
+		return fmt.Errorf("Timer for project %d is already running", projectId)
+	}
+
+	query := "UPDATE timers SET resumed=?, running=TRUE WHERE projectId=?;"
+	if _, err := db.db.Exec(query, now, projectId); err != nil {
+		return fmt.Errorf("Unable to resume timer: %s", err.Error())
+	}
+	return nil
+}
+
+func (db *Database) PauseTimer(projectId uint64) error {
+	return db.transaction(func(tx *Database) error {
+		return tx.pauseTimer(projectId)
+	})
 }
 
-func (db *Database) AddSession(projectId, duration, date uint64) error {
-	query := "INSERT INTO sessions (projectId, timestamp, duration) VALUES (?, ?, ?)"
-	_, err := db.db.Exec(query, projectId, date, duration)
+func (db *Database) pauseTimer(projectId uint64) error {
+	timer, err := db.GetTimer(projectId)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("No timer is running for project %d", projectId)
+	}
+
 	if err != nil {
-		return fmt.Errorf("Unable to create project: %s", err.Error())
+		return fmt.Errorf("Unable to get timer for project %d: %s", projectId, err.Error())
+	}
+
+	if !timer.Running {
+		return fmt.Errorf("Timer for project %d is already paused", projectId)
+	}
+
+	elapsed := timer.ElapsedAt(time.Now())
+	query := "UPDATE timers SET elapsed=?, running=FALSE WHERE projectId=?;"
+	if _, err := db.db.Exec(query, elapsed, projectId); err != nil {
+		return fmt.Errorf("Unable to pause timer: %s", err.Error())
 	}
 	return nil
 }
 
-func (db *Database) DeleteSession(id uint64) (uint64, error) {
-	query := "SELECT projectId FROM sessions WHERE id = ?"
-	var projectId uint64
-	if err := db.db.QueryRow(query, id).Scan(&projectId); err != nil {
-		return 0, fmt.Errorf("Unable to get project id for session %s", err.Error())
+// Stop the timer and record the time it measured as a session; returns
+// the duration of the session in minutes
+func (db *Database) StopTimer(projectId uint64) (duration uint64, err error) {
+	err = db.transaction(func(tx *Database) error {
+		duration, err = tx.stopTimer(projectId)
+		return err
+	})
+	return
+}
+
+func (db *Database) stopTimer(projectId uint64) (uint64, error) {
+	timer, err := db.GetTimer(projectId)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("No timer is running for project %d", projectId)
 	}
 
-	query = "DELETE FROM sessions WHERE id = ?"
-	if _, err := db.db.Exec(query, id); err != nil {
-		return 0, fmt.Errorf("Unable to delete session: %s", err.Error())
+	if err != nil {
+		return 0, fmt.Errorf("Unable to get timer for project %d: %s", projectId, err.Error())
 	}
-	return projectId, nil
+
+	// Sessions are measured in minutes, so we round to the nearest one
+	duration := (timer.ElapsedAt(time.Now()) + 30) / 60
+	if duration != 0 {
+		if err := db.AddSession(projectId, 0, duration, timer.Started, ""); err != nil {
+			return 0, err
+		}
+	}
+
+	query := "DELETE FROM timers WHERE projectId = ?;"
+	if _, err := db.db.Exec(query, projectId); err != nil {
+		return 0, fmt.Errorf("Unable to delete timer: %s", err.Error())
+	}
+	return duration, nil
+}
+
+func (db *Database) Batch(fn func(store Store) error) error {
+	return db.transaction(func(tx *Database) error { return fn(tx) })
 }
 
-func NewDatabase(dbDir string) (*Database, error) {
+// Open the database without migrating its schema
+func OpenDatabase(opts *BackendOpts) (*Database, error) {
 	db := new(Database)
-	err := os.MkdirAll(dbDir, os.ModePerm)
+	periods, err := NewPeriods(opts)
+	if err != nil {
+		return nil, err
+	}
+	db.periods = periods
+
+	switch opts.Driver {
+	case "", "sqlite3":
+		dbDir := opts.DatabaseDirectory
+		err = os.MkdirAll(dbDir, os.ModePerm)
+		if err != nil {
+			return nil, err
+		}
+		err = db.initialize(dbDir)
+	case "postgres":
+		err = db.initializePostgres(opts.DSN)
+	default:
+		err = fmt.Errorf("Unknown database driver: %s", opts.Driver)
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	err = db.initialize(dbDir)
+	return db, nil
+}
+
+func NewDatabase(opts *BackendOpts) (*Database, error) {
+	db, err := OpenDatabase(opts)
 	if err != nil {
 		return nil, err
 	}
 
+	if err = db.Migrate(db.GetLatestSchemaVersion()); err != nil {
+		db.db.Close()
+		return nil, err
+	}
+
 	return db, nil
 }
@@ -0,0 +1,188 @@
+//------------------------------------------------------------------------------
+// Author: Lukasz Janyst <lukasz@jany.st>
+// Date: 17.10.2026
+//
+// Licensed under the GPL 3 License, see the LICENSE file for details.
+//------------------------------------------------------------------------------
+
+package reef
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// The bits of SQL that differ between the database engines; the queries are
+// written with question mark placeholders and rebound where needed
+type dialect interface {
+	// Rewrite the placeholders of the query to the syntax of the engine
+	rebind(query string) string
+
+	// Join the values of the expression into a string with the separator
+	groupConcat(expr, separator string) string
+
+	// Local date, as YYYY-MM-DD, of the day, week or month the unix
+	// timestamp falls into
+	dateBucket(groupBy, column string, zoneOffset int, weekStart time.Weekday) string
+
+	// Run the insert statement and return the id of the new row
+	insert(tx *dbConn, query string, args ...interface{}) (uint64, error)
+
+	isUniqueViolation(err error) bool
+
+	// Options of the transactions that need a consistent view of the whole
+	// database across several queries
+	snapshotOptions() *sql.TxOptions
+}
+
+//------------------------------------------------------------------------------
+// SQLite
+//------------------------------------------------------------------------------
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) rebind(query string) string {
+	return query
+}
+
+func (sqliteDialect) groupConcat(expr, separator string) string {
+	return fmt.Sprintf("group_concat(%s, '%s')", expr, separator)
+}
+
+func (sqliteDialect) dateBucket(groupBy, column string, zoneOffset int,
+	weekStart time.Weekday) string {
+	local := fmt.Sprintf("%s + %d, 'unixepoch'", column, zoneOffset)
+	switch groupBy {
+	case GroupByWeek:
+		return fmt.Sprintf("date(%s, '-' || ((strftime('%%w', %s) + %d) %% 7) || ' days')",
+			local, local, 7-int(weekStart))
+	case GroupByMonth:
+		return "strftime('%Y-%m-01', " + local + ")"
+	}
+	return "date(" + local + ")"
+}
+
+func (sqliteDialect) insert(tx *dbConn, query string, args ...interface{}) (uint64, error) {
+	res, err := tx.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	return uint64(id), err
+}
+
+func (sqliteDialect) isUniqueViolation(err error) bool {
+	return strings.Contains(err.Error(), "UNIQUE constraint")
+}
+
+// The SQLite transactions are serializable already
+func (sqliteDialect) snapshotOptions() *sql.TxOptions {
+	return nil
+}
+
+//------------------------------------------------------------------------------
+// Connections rebinding the queries for their dialect
+//------------------------------------------------------------------------------
+
+// The queries go through the transaction if there is one
+type dbConn struct {
+	*sql.DB
+	tx      *sql.Tx
+	dialect dialect
+}
+
+func (c *dbConn) Exec(query string, args ...interface{}) (sql.Result, error) {
+	if c.tx != nil {
+		return c.tx.Exec(c.dialect.rebind(query), args...)
+	}
+	return c.DB.Exec(c.dialect.rebind(query), args...)
+}
+
+func (c *dbConn) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	if c.tx != nil {
+		return c.tx.Query(c.dialect.rebind(query), args...)
+	}
+	return c.DB.Query(c.dialect.rebind(query), args...)
+}
+
+func (c *dbConn) QueryRow(query string, args ...interface{}) *sql.Row {
+	if c.tx != nil {
+		return c.tx.QueryRow(c.dialect.rebind(query), args...)
+	}
+	return c.DB.QueryRow(c.dialect.rebind(query), args...)
+}
+
+// Run fn on a copy of the database bound to a transaction, which is committed
+// if fn succeeds and rolled back otherwise; calls made by fn join the
+// transaction instead of starting their own
+func (db *Database) transaction(fn func(tx *Database) error) error {
+	return db.transactionWith(nil, fn)
+}
+
+// Run fn in a transaction seeing a single snapshot of the database
+func (db *Database) readTransaction(fn func(tx *Database) error) error {
+	return db.transactionWith(db.db.dialect.snapshotOptions(), fn)
+}
+
+func (db *Database) transactionWith(opts *sql.TxOptions, fn func(tx *Database) error) error {
+	if db.db.tx != nil {
+		return fn(db)
+	}
+
+	sqlTx, err := db.db.DB.BeginTx(context.Background(), opts)
+	if err != nil {
+		return fmt.Errorf("Unable to start a transaction: %s", err)
+	}
+	defer sqlTx.Rollback()
+
+	tx := *db
+	tx.db = &dbConn{db.db.DB, sqlTx, db.db.dialect}
+	if err := fn(&tx); err != nil {
+		return err
+	}
+	if err := sqlTx.Commit(); err != nil {
+		return fmt.Errorf("Unable to commit the transaction: %s", err)
+	}
+	return nil
+}
+
+// Replace the question marks outside of the string literals with numbered
+// placeholders starting with the prefix
+func numberPlaceholders(query, prefix string) string {
+	var b strings.Builder
+	quoted := false
+	n := 0
+	for _, r := range query {
+		switch {
+		case r == '\'':
+			quoted = !quoted
+		case r == '?' && !quoted:
+			n++
+			fmt.Fprintf(&b, "%s%d", prefix, n)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// SQLite returns the DATETIME columns as time.Time while other engines store
+// the session timestamps as plain integers
+type sessionTime struct {
+	time.Time
+}
+
+func (t *sessionTime) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case time.Time:
+		t.Time = v
+	case int64:
+		t.Time = time.Unix(v, 0)
+	default:
+		return fmt.Errorf("Unable to convert %T to a timestamp", value)
+	}
+	return nil
+}
@@ -0,0 +1,236 @@
+//------------------------------------------------------------------------------
+// Author: Lukasz Janyst <lukasz@jany.st>
+// Date: 17.10.2026
+//
+// Licensed under the GPL 3 License, see the LICENSE file for details.
+//------------------------------------------------------------------------------
+
+package reef
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Sessions logged in the [From, To) range in projects tagged with any of the
+// tags or their descendants; no tags means all the projects
+type SessionFilter struct {
+	From   uint64
+	To     uint64
+	TagIds []uint64
+}
+
+type ExportedSession struct {
+	Id        uint64   `json:"id"`
+	Date      string   `json:"date"`
+	Timestamp uint64   `json:"timestamp"`
+	ProjectId uint64   `json:"projectId"`
+	Project   string   `json:"project"`
+	Tags      []string `json:"tags"`
+	Task      string   `json:"task"`
+	Duration  uint64   `json:"duration"`
+	Note      string   `json:"note"`
+}
+
+// Build a session filter out of inclusive YYYY-MM-DD dates in the configured
+// time zone and tag ids or names; empty values don't restrict the export
+func (db *Database) NewSessionFilter(from, to string, tags []string) (SessionFilter, error) {
+	filter := SessionFilter{From: 0, To: math.MaxInt64, TagIds: []uint64{}}
+
+	if from != "" {
+		date, err := time.ParseInLocation("2006-01-02", from, db.periods.Location)
+		if err != nil {
+			return SessionFilter{}, fmt.Errorf("Malformed start date %s: %s", from, err)
+		}
+		filter.From = uint64(date.Unix())
+	}
+
+	if to != "" {
+		date, err := time.ParseInLocation("2006-01-02", to, db.periods.Location)
+		if err != nil {
+			return SessionFilter{}, fmt.Errorf("Malformed end date %s: %s", to, err)
+		}
+		filter.To = uint64(date.AddDate(0, 0, 1).Unix())
+	}
+
+	for _, tag := range tags {
+		if tag == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(tag, 10, 64)
+		if err != nil {
+			query := "SELECT id FROM tags WHERE name = ?;"
+			if err := db.db.QueryRow(query, tag).Scan(&id); err != nil {
+				return SessionFilter{}, fmt.Errorf("Unable to find tag %s: %s", tag, err)
+			}
+		}
+		filter.TagIds = append(filter.TagIds, id)
+	}
+
+	return filter, nil
+}
+
+// Number of sessions read from the database at a time during an export
+var exportPageSize = 256
+
+// Call fn for every session matching the filter in chronological order; the
+// sessions are read a page at a time and each page is read in full before fn
+// sees it, so that fn can write to a slow client without holding a read lock
+// that blocks the writers
+func (db *Database) forEachSession(filter SessionFilter, fn func(ExportedSession) error) error {
+	var lastTimestamp, lastId uint64
+	for first := true; ; first = false {
+		sessions, err := db.getSessionPage(filter, first, lastTimestamp, lastId)
+		if err != nil {
+			return err
+		}
+		for _, session := range sessions {
+			if err := fn(session); err != nil {
+				return err
+			}
+		}
+		if len(sessions) < exportPageSize {
+			return nil
+		}
+		lastTimestamp = sessions[len(sessions)-1].Timestamp
+		lastId = sessions[len(sessions)-1].Id
+	}
+}
+
+// Get the page of sessions matching the filter that follows the given session
+func (db *Database) getSessionPage(filter SessionFilter, first bool, lastTimestamp,
+	lastId uint64) ([]ExportedSession, error) {
+	with, where, args := buildReportFilter(filter.From, filter.To, []uint64{}, filter.TagIds)
+	if !first {
+		where += "AND (sessions.timestamp > ? OR " +
+			"(sessions.timestamp = ? AND sessions.id > ?)) "
+		args = append(args, lastTimestamp, lastTimestamp, lastId)
+	}
+	query := with + "SELECT sessions.id, sessions.timestamp, sessions.duration, " +
+		"sessions.note, projects.id, projects.title, COALESCE(tasks.title, ''), " +
+		"(SELECT " + db.db.dialect.groupConcat("tags.name", "\x1f") + " FROM projectTags " +
+		"JOIN tags ON tags.id = projectTags.tagId " +
+		"WHERE projectTags.projectId = sessions.projectId) " +
+		"FROM sessions JOIN projects ON projects.id = sessions.projectId " +
+		"LEFT JOIN tasks ON tasks.id = sessions.taskId " +
+		where + "ORDER BY sessions.timestamp, sessions.id " +
+		fmt.Sprintf("LIMIT %d;", exportPageSize)
+	rows, err := db.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to query sessions: %s", err)
+	}
+	defer rows.Close()
+
+	sessions := []ExportedSession{}
+	for rows.Next() {
+		var session ExportedSession
+		var dt sessionTime
+		var tags *string
+		err := rows.Scan(&session.Id, &dt, &session.Duration, &session.Note,
+			&session.ProjectId, &session.Project, &session.Task, &tags)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to scan sessions: %s", err)
+		}
+		session.Timestamp = uint64(dt.Unix())
+		session.Date = dt.In(db.periods.Location).Format("2006-01-02 15:04")
+		session.Tags = []string{}
+		if tags != nil {
+			session.Tags = strings.Split(*tags, "\x1f")
+		}
+		sessions = append(sessions, session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Unable to process sessions: %s", err)
+	}
+	return sessions, nil
+}
+
+// Stream the sessions matching the filter as csv or json
+func (db *Database) ExportSessions(w io.Writer, format string, filter SessionFilter) error {
+	switch format {
+	case "csv":
+		writer := csv.NewWriter(w)
+		header := []string{"id", "date", "project", "tags", "task", "duration", "note"}
+		if err := writer.Write(header); err != nil {
+			return err
+		}
+		err := db.forEachSession(filter, func(s ExportedSession) error {
+			return writer.Write([]string{
+				strconv.FormatUint(s.Id, 10), s.Date, s.Project, strings.Join(s.Tags, ";"),
+				s.Task, strconv.FormatUint(s.Duration, 10), s.Note,
+			})
+		})
+		if err != nil {
+			return err
+		}
+		writer.Flush()
+		return writer.Error()
+
+	case "json":
+		separator := "\n"
+		if _, err := io.WriteString(w, "["); err != nil {
+			return err
+		}
+		err := db.forEachSession(filter, func(s ExportedSession) error {
+			data, err := json.Marshal(s)
+			if err != nil {
+				return err
+			}
+			if _, err := io.WriteString(w, separator); err != nil {
+				return err
+			}
+			separator = ",\n"
+			_, err = w.Write(data)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, "\n]\n")
+		return err
+	}
+	return fmt.Errorf("Unknown export format: %s", format)
+}
+
+type SessionExportHandler struct {
+	db     *Database
+	format string
+}
+
+func (handler SessionExportHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter, err := handler.db.NewSessionFilter(query.Get("from"), query.Get("to"),
+		query["tag"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	contentType := "text/csv; charset=utf-8"
+	if handler.format == "json" {
+		contentType = "application/json"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition",
+		fmt.Sprintf(`attachment; filename="sessions.%s"`, handler.format))
+
+	if err := handler.db.ExportSessions(w, handler.format, filter); err != nil {
+		log.Errorf("Unable to export sessions: %s", err)
+	}
+}
+
+func NewSessionExportHandler(db *Database, format string) SessionExportHandler {
+	var h SessionExportHandler
+	h.db = db
+	h.format = format
+	return h
+}
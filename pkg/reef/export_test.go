@@ -0,0 +1,144 @@
+//------------------------------------------------------------------------------
+// Author: Lukasz Janyst <lukasz@jany.st>
+// Date: 17.10.2026
+//
+// Licensed under the GPL 3 License, see the LICENSE file for details.
+//------------------------------------------------------------------------------
+
+package reef
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// Client that changes the database while it receives the export
+type writingClient struct {
+	t      *testing.T
+	db     *Database
+	writes int
+}
+
+func (c *writingClient) Write(data []byte) (int, error) {
+	c.writes++
+	if err := c.db.AddSession(1, 0, 5, uint64(time.Now().Unix()), "during export"); err != nil {
+		c.t.Errorf("Unable to write during the export: %s", err)
+	}
+	return len(data), nil
+}
+
+func TestExportDoesNotBlockWriters(t *testing.T) {
+	db, cleanup := newTempDatabase(t)
+	defer cleanup()
+
+	if _, err := db.CreateProject("Alpha", "", []uint64{}, 0); err != nil {
+		t.Fatalf("Unable to create the project: %s", err)
+	}
+	now := uint64(time.Now().Unix())
+	for i := uint64(0); i < 3; i++ {
+		if err := db.AddSession(1, 0, 30, now-3600*(i+1), ""); err != nil {
+			t.Fatalf("Unable to add a session: %s", err)
+		}
+	}
+
+	filter, err := db.NewSessionFilter("", "", []string{})
+	if err != nil {
+		t.Fatalf("Unable to create the filter: %s", err)
+	}
+	client := &writingClient{t: t, db: db}
+	if err := db.ExportSessions(client, "json", filter); err != nil {
+		t.Fatalf("Unable to export the sessions: %s", err)
+	}
+	if client.writes < 4 {
+		t.Errorf("The export was not streamed: %d writes", client.writes)
+	}
+}
+
+func TestExportSessions(t *testing.T) {
+	db, cleanup := newTempDatabase(t)
+	defer cleanup()
+	periods, err := NewPeriods(&BackendOpts{TimeZone: "Europe/Warsaw"})
+	if err != nil {
+		t.Fatalf("Unable to create the periods: %s", err)
+	}
+	db.periods = periods
+
+	// Every session is read with a query of its own
+	defer func(size int) { exportPageSize = size }(exportPageSize)
+	exportPageSize = 1
+
+	steps := []func() error{
+		func() error { _, err := db.CreateTag("clients", "#ff0000", 0); return err },
+		func() error { _, err := db.CreateTag("ops", "#00ff00", 0); return err },
+		func() error { _, err := db.CreateTag("internal", "#0000ff", 0); return err },
+		func() error { _, err := db.CreateProject("Alpha", "", []uint64{1, 2}, 0); return err },
+		func() error { _, err := db.CreateProject("Beta", "", []uint64{3}, 0); return err },
+		func() error { return db.AddTask(1, 0, TaskDetails{Title: "Write"}) },
+		func() error { return db.AddSession(1, 1, 30, warsawTime(t, 10, 20, 23, 30), "first") },
+		func() error { return db.AddSession(2, 0, 45, warsawTime(t, 10, 21, 10, 0), "with, comma") },
+		func() error { return db.AddSession(1, 0, 15, warsawTime(t, 10, 22, 8, 0), "") },
+		func() error { return db.AddSession(2, 0, 20, warsawTime(t, 10, 22, 8, 0), "") },
+	}
+	for i, step := range steps {
+		if err := step(); err != nil {
+			t.Fatalf("Unable to populate the database, step %d: %s", i, err)
+		}
+	}
+
+	export := func(format, from, to string, tags []string) string {
+		filter, err := db.NewSessionFilter(from, to, tags)
+		if err != nil {
+			t.Fatalf("Unable to create the filter: %s", err)
+		}
+		var b bytes.Buffer
+		if err := db.ExportSessions(&b, format, filter); err != nil {
+			t.Fatalf("Unable to export the sessions: %s", err)
+		}
+		return b.String()
+	}
+
+	// The dates are in the configured time zone
+	expected := "id,date,project,tags,task,duration,note\n" +
+		"1,2026-10-20 23:30,Alpha,clients;ops,Write,30,first\n" +
+		"2,2026-10-21 10:00,Beta,internal,,45,\"with, comma\"\n" +
+		"3,2026-10-22 08:00,Alpha,clients;ops,,15,\n" +
+		"4,2026-10-22 08:00,Beta,internal,,20,\n"
+	if actual := export("csv", "", "", []string{}); actual != expected {
+		t.Errorf("Wrong csv export:\n%s\nExpected:\n%s", actual, expected)
+	}
+
+	var sessions []ExportedSession
+	if err := json.Unmarshal([]byte(export("json", "", "", []string{})), &sessions); err != nil {
+		t.Fatalf("Unable to parse the json export: %s", err)
+	}
+	expectedSession := ExportedSession{Id: 1, Date: "2026-10-20 23:30",
+		Timestamp: warsawTime(t, 10, 20, 23, 30), ProjectId: 1, Project: "Alpha",
+		Tags: []string{"clients", "ops"}, Task: "Write", Duration: 30, Note: "first"}
+	if len(sessions) != 4 || !reflect.DeepEqual(sessions[0], expectedSession) {
+		t.Errorf("Wrong json export: %+v", sessions)
+	}
+
+	filters := []struct {
+		from, to string
+		tags     []string
+		expected string
+	}{
+		{"2026-10-21", "2026-10-21", []string{},
+			"2,2026-10-21 10:00,Beta,internal,,45,\"with, comma\"\n"},
+		{"2026-10-22", "", []string{"ops"},
+			"3,2026-10-22 08:00,Alpha,clients;ops,,15,\n"},
+		{"", "", []string{"3"},
+			"2,2026-10-21 10:00,Beta,internal,,45,\"with, comma\"\n" +
+				"4,2026-10-22 08:00,Beta,internal,,20,\n"},
+	}
+	for _, f := range filters {
+		expected := "id,date,project,tags,task,duration,note\n" + f.expected
+		if actual := export("csv", f.from, f.to, f.tags); actual != expected {
+			t.Errorf("Wrong export from %q to %q tagged %v:\n%s\nExpected:\n%s", f.from, f.to,
+				f.tags, actual, expected)
+		}
+	}
+}
@@ -0,0 +1,1794 @@
+//------------------------------------------------------------------------------
+// Author: Lukasz Janyst <lukasz@jany.st>
+// Date: 17.10.2026
+//
+// Licensed under the GPL 3 License, see the LICENSE file for details.
+//------------------------------------------------------------------------------
+
+package reef
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+type memoryTag struct {
+	Id       uint64
+	Name     string
+	Color    string
+	ParentId uint64
+}
+
+type memoryProject struct {
+	Id          uint64
+	Title       string
+	Description string
+	ParentId    uint64
+	Status      string
+	Tags        []uint64
+	History     []StatusChange
+}
+
+type memorySession struct {
+	Session
+	ProjectId uint64
+}
+
+// MemoryStore keeps everything in memory and is lost when the process exits;
+// it behaves like the SQLite database, down to the error messages, so that
+// it can stand in for it in tests and in embedding applications. The rows of
+// every table are kept in the order of their ids.
+type MemoryStore struct {
+	mutex   sync.Locker
+	periods Periods
+	*memoryState
+}
+
+// The lock of a batch view; the store it comes from is locked for the whole
+// batch already
+type noLock struct{}
+
+func (noLock) Lock()   {}
+func (noLock) Unlock() {}
+
+type memoryState struct {
+	tags       []*memoryTag
+	projects   []*memoryProject
+	tasks      []*Task
+	milestones []*Milestone
+	goals      []*Goal
+	sessions   []*memorySession
+	timers     []*Timer
+
+	lastTagId       uint64
+	lastProjectId   uint64
+	lastTaskId      uint64
+	lastMilestoneId uint64
+	lastGoalId      uint64
+	lastSessionId   uint64
+}
+
+// Deep copy of the state that doesn't share anything with the original
+func (s *memoryState) clone() memoryState {
+	c := *s
+	c.tags = []*memoryTag{}
+	for _, tag := range s.tags {
+		t := *tag
+		c.tags = append(c.tags, &t)
+	}
+	c.projects = []*memoryProject{}
+	for _, project := range s.projects {
+		p := *project
+		p.Tags = copyIds(project.Tags)
+		p.History = append([]StatusChange{}, project.History...)
+		c.projects = append(c.projects, &p)
+	}
+	c.tasks = []*Task{}
+	for _, task := range s.tasks {
+		t := *task
+		t.Tags = copyIds(task.Tags)
+		t.BlockedBy = copyIds(task.BlockedBy)
+		c.tasks = append(c.tasks, &t)
+	}
+	c.milestones = []*Milestone{}
+	for _, milestone := range s.milestones {
+		m := *milestone
+		m.Tasks = copyIds(milestone.Tasks)
+		c.milestones = append(c.milestones, &m)
+	}
+	c.goals = []*Goal{}
+	for _, goal := range s.goals {
+		g := *goal
+		c.goals = append(c.goals, &g)
+	}
+	c.sessions = []*memorySession{}
+	for _, session := range s.sessions {
+		ms := *session
+		c.sessions = append(c.sessions, &ms)
+	}
+	c.timers = []*Timer{}
+	for _, timer := range s.timers {
+		t := *timer
+		c.timers = append(c.timers, &t)
+	}
+	return c
+}
+
+// Insert the id into a sorted list unless it is already there
+func insertId(lst []uint64, id uint64) []uint64 {
+	idx := sort.Search(len(lst), func(i int) bool { return lst[i] >= id })
+	if idx < len(lst) && lst[idx] == id {
+		return lst
+	}
+	lst = append(lst, 0)
+	copy(lst[idx+1:], lst[idx:])
+	lst[idx] = id
+	return lst
+}
+
+func removeId(lst []uint64, id uint64) []uint64 {
+	result := []uint64{}
+	for _, elem := range lst {
+		if elem != id {
+			result = append(result, elem)
+		}
+	}
+	return result
+}
+
+func copyIds(lst []uint64) []uint64 {
+	return append([]uint64{}, lst...)
+}
+
+func (m *MemoryStore) findTag(id uint64) *memoryTag {
+	for _, tag := range m.tags {
+		if tag.Id == id {
+			return tag
+		}
+	}
+	return nil
+}
+
+func (m *MemoryStore) findProject(id uint64) *memoryProject {
+	for _, project := range m.projects {
+		if project.Id == id {
+			return project
+		}
+	}
+	return nil
+}
+
+func (m *MemoryStore) findTask(id uint64) *Task {
+	for _, task := range m.tasks {
+		if task.Id == id {
+			return task
+		}
+	}
+	return nil
+}
+
+func (m *MemoryStore) findMilestone(id uint64) *Milestone {
+	for _, milestone := range m.milestones {
+		if milestone.Id == id {
+			return milestone
+		}
+	}
+	return nil
+}
+
+func (m *MemoryStore) findGoal(id uint64) *Goal {
+	for _, goal := range m.goals {
+		if goal.Id == id {
+			return goal
+		}
+	}
+	return nil
+}
+
+func (m *MemoryStore) findSession(id uint64) *memorySession {
+	for _, session := range m.sessions {
+		if session.Id == id {
+			return session
+		}
+	}
+	return nil
+}
+
+func (m *MemoryStore) findTimer(projectId uint64) *Timer {
+	for _, timer := range m.timers {
+		if timer.ProjectId == projectId {
+			return timer
+		}
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+// Tags
+//------------------------------------------------------------------------------
+
+// Get the ids of the tag and all of its descendants, breadth first
+func (m *MemoryStore) tagSubtree(id uint64) []uint64 {
+	subtree := []uint64{id}
+	for i := 0; i < len(subtree); i++ {
+		for _, tag := range m.tags {
+			if tag.ParentId == subtree[i] {
+				subtree = append(subtree, tag.Id)
+			}
+		}
+	}
+	return subtree
+}
+
+func (m *MemoryStore) tagAncestors(id uint64) []uint64 {
+	ancestors := []uint64{}
+	for tag := m.findTag(id); tag != nil && tag.ParentId != 0; tag = m.findTag(tag.ParentId) {
+		ancestors = append(ancestors, tag.ParentId)
+	}
+	return ancestors
+}
+
+func (m *MemoryStore) goalsOf(projectId, tagId uint64, durations Durations) []Goal {
+	goals := []Goal{}
+	for _, goal := range m.goals {
+		if (projectId != 0 && goal.ProjectId == projectId) ||
+			(tagId != 0 && goal.TagId == tagId) {
+			g := *goal
+			computeGoalProgress(&g, durations)
+			goals = append(goals, g)
+		}
+	}
+	return goals
+}
+
+func (m *MemoryStore) tag(id uint64) (Tag, error) {
+	t := m.findTag(id)
+	if t == nil {
+		return Tag{}, fmt.Errorf("Cannot query tag: %s", sql.ErrNoRows)
+	}
+	tag := Tag{Id: t.Id, Name: t.Name, Color: t.Color, ParentId: t.ParentId}
+
+	subtree := m.tagSubtree(id)
+	counted := make(map[uint64]bool)
+	for _, project := range m.projects {
+		if inList(id, project.Tags) {
+			tag.NumberOfProjects++
+		}
+		for _, tagId := range project.Tags {
+			if inList(tagId, subtree) {
+				counted[project.Id] = true
+			}
+		}
+	}
+
+	for _, task := range m.tasks {
+		if inList(id, task.Tags) {
+			tag.NumberOfTasks++
+		}
+	}
+
+	// A project tagged with more than one tag of the subtree is counted once
+	var durations Durations
+	monthStart, weekStart := m.periods.Bounds(time.Now())
+	for _, session := range m.sessions {
+		if counted[session.ProjectId] {
+			durations.add(session.Duration, time.Unix(int64(session.Date), 0), monthStart,
+				weekStart)
+		}
+	}
+	tag.DurationTotal = durations.DurationTotal
+	tag.DurationMonth = durations.DurationMonth
+	tag.DurationWeek = durations.DurationWeek
+	tag.Goals = m.goalsOf(0, id, durations)
+	return tag, nil
+}
+
+func (m *MemoryStore) CreateTag(name string, color string, parentId uint64) (uint64, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if parentId != 0 && m.findTag(parentId) == nil {
+		return 0, fmt.Errorf("Unable to find parent tag %d: %s", parentId, sql.ErrNoRows)
+	}
+
+	for _, tag := range m.tags {
+		if tag.Name == name {
+			return 0, fmt.Errorf("Unable to insert tag: %s already exists", name)
+		}
+	}
+
+	m.lastTagId++
+	m.tags = append(m.tags, &memoryTag{m.lastTagId, name, color, parentId})
+	return m.lastTagId, nil
+}
+
+func (m *MemoryStore) DeleteTag(id uint64) ([]uint64, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	projIds := []uint64{}
+	for _, project := range m.projects {
+		if inList(id, project.Tags) {
+			projIds = append(projIds, project.Id)
+			project.Tags = removeId(project.Tags, id)
+		}
+	}
+
+	// Projects with tagged tasks need to be refreshed as well
+	for _, task := range m.tasks {
+		if inList(id, task.Tags) {
+			if !inList(task.ProjectId, projIds) {
+				projIds = append(projIds, task.ProjectId)
+			}
+			task.Tags = removeId(task.Tags, id)
+		}
+	}
+
+	goals := []*Goal{}
+	for _, goal := range m.goals {
+		if goal.TagId != id {
+			goals = append(goals, goal)
+		}
+	}
+	m.goals = goals
+
+	tag := m.findTag(id)
+	if tag == nil {
+		return projIds, nil
+	}
+
+	// The children of the tag are handed over to its parent
+	tags := []*memoryTag{}
+	for _, t := range m.tags {
+		if t.ParentId == id {
+			t.ParentId = tag.ParentId
+		}
+		if t.Id != id {
+			tags = append(tags, t)
+		}
+	}
+	m.tags = tags
+	return projIds, nil
+}
+
+func (m *MemoryStore) EditTag(id uint64, newName, newColor string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	tag := m.findTag(id)
+	if tag == nil {
+		return nil
+	}
+
+	for _, t := range m.tags {
+		if t.Id != id && t.Name == newName {
+			return fmt.Errorf("Unable to rename tag: %s already exists", newName)
+		}
+	}
+	tag.Name = newName
+	tag.Color = newColor
+	return nil
+}
+
+func (m *MemoryStore) MoveTag(id, parentId uint64) (uint64, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	tag := m.findTag(id)
+	if tag == nil {
+		return 0, fmt.Errorf("Unable to find tag %d: %s", id, sql.ErrNoRows)
+	}
+
+	if parentId != 0 {
+		if m.findTag(parentId) == nil {
+			return 0, fmt.Errorf("Unable to find parent tag %d: %s", parentId, sql.ErrNoRows)
+		}
+
+		if inList(parentId, m.tagSubtree(id)) {
+			return 0, fmt.Errorf("Unable to move tag: tag %d is a descendant of tag %d",
+				parentId, id)
+		}
+	}
+
+	oldParentId := tag.ParentId
+	tag.ParentId = parentId
+	return oldParentId, nil
+}
+
+func (m *MemoryStore) GetTagById(id uint64) (Tag, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.tag(id)
+}
+
+func (m *MemoryStore) GetTagList() ([]Tag, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	var tags []Tag
+	for _, t := range m.tags {
+		tag, err := m.tag(t.Id)
+		if err != nil {
+			return []Tag{}, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
+
+func (m *MemoryStore) GetTagChildIds(id uint64) ([]uint64, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	ids := []uint64{}
+	for _, tag := range m.tags {
+		if tag.ParentId == id {
+			ids = append(ids, tag.Id)
+		}
+	}
+	return ids, nil
+}
+
+func (m *MemoryStore) GetTagAncestorIds(id uint64) ([]uint64, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.tagAncestors(id), nil
+}
+
+func (m *MemoryStore) GetTagIdsByProjectId(id uint64) ([]uint64, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if project := m.findProject(id); project != nil {
+		return copyIds(project.Tags), nil
+	}
+	return []uint64{}, nil
+}
+
+//------------------------------------------------------------------------------
+// Projects
+//------------------------------------------------------------------------------
+
+func (m *MemoryStore) projectSubtree(id uint64) []uint64 {
+	subtree := []uint64{id}
+	for i := 0; i < len(subtree); i++ {
+		for _, project := range m.projects {
+			if project.ParentId == subtree[i] {
+				subtree = append(subtree, project.Id)
+			}
+		}
+	}
+	return subtree
+}
+
+func (m *MemoryStore) projectChildren(id uint64) []uint64 {
+	ids := []uint64{}
+	for _, project := range m.projects {
+		if project.ParentId == id {
+			ids = append(ids, project.Id)
+		}
+	}
+	return ids
+}
+
+func (m *MemoryStore) projectAncestors(id uint64) []uint64 {
+	ancestors := []uint64{}
+	for p := m.findProject(id); p != nil && p.ParentId != 0; p = m.findProject(p.ParentId) {
+		ancestors = append(ancestors, p.ParentId)
+	}
+	return ancestors
+}
+
+// Copy the task and work out whether any of its blockers is unfinished
+func (m *MemoryStore) task(t *Task) Task {
+	task := *t
+	task.Tags = copyIds(t.Tags)
+	task.BlockedBy = copyIds(t.BlockedBy)
+	for _, blockerId := range t.BlockedBy {
+		if blocker := m.findTask(blockerId); blocker != nil && !blocker.Done {
+			task.Blocked = true
+		}
+	}
+	return task
+}
+
+// Get the tasks of the project ordered by their position
+func (m *MemoryStore) projectTasks(id uint64) []Task {
+	tasks := []Task{}
+	for _, t := range m.tasks {
+		if t.ProjectId == id {
+			tasks = append(tasks, m.task(t))
+		}
+	}
+	sort.SliceStable(tasks, func(i, j int) bool {
+		return tasks[i].Position < tasks[j].Position
+	})
+	return tasks
+}
+
+func (m *MemoryStore) completeness(id uint64) float32 {
+	tasks := m.projectTasks(id)
+	children := m.projectChildren(id)
+	if len(children) == 0 {
+		return computeCompleteness(tasks)
+	}
+
+	var sum float32
+	var parts uint64
+	if len(tasks) != 0 {
+		sum += computeCompleteness(tasks)
+		parts++
+	}
+
+	for _, childId := range children {
+		sum += m.completeness(childId)
+		parts++
+	}
+	return sum / float32(parts)
+}
+
+func (m *MemoryStore) summary(p *memoryProject) Summary {
+	return Summary{
+		Id:           p.Id,
+		Title:        p.Title,
+		ParentId:     p.ParentId,
+		Status:       p.Status,
+		Tags:         copyIds(p.Tags),
+		Completeness: m.completeness(p.Id),
+	}
+}
+
+func (m *MemoryStore) summaryList() []Summary {
+	summaries := []Summary{}
+	for _, project := range m.projects {
+		summaries = append(summaries, m.summary(project))
+	}
+	return summaries
+}
+
+func (m *MemoryStore) CreateProject(title, description string, tags []uint64,
+	parentId uint64) (uint64, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if parentId != 0 && m.findProject(parentId) == nil {
+		return 0, fmt.Errorf("Unable to find parent project %d: %s", parentId, sql.ErrNoRows)
+	}
+
+	for _, project := range m.projects {
+		if project.Title == title {
+			return 0, fmt.Errorf("Unable to create project: %s already exists", title)
+		}
+	}
+
+	m.lastProjectId++
+	project := &memoryProject{
+		Id:          m.lastProjectId,
+		Title:       title,
+		Description: description,
+		ParentId:    parentId,
+		Status:      ProjectActive,
+		Tags:        []uint64{},
+		History:     []StatusChange{{ProjectActive, uint64(time.Now().Unix())}},
+	}
+	for _, tagId := range tags {
+		project.Tags = insertId(project.Tags, tagId)
+	}
+	m.projects = append(m.projects, project)
+	return project.Id, nil
+}
+
+func (m *MemoryStore) DeleteProject(id uint64) ([]uint64, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	project := m.findProject(id)
+	if project == nil {
+		return []uint64{}, nil
+	}
+
+	goals := []*Goal{}
+	for _, goal := range m.goals {
+		if goal.ProjectId != id {
+			goals = append(goals, goal)
+		}
+	}
+	m.goals = goals
+
+	milestones := []*Milestone{}
+	for _, milestone := range m.milestones {
+		if milestone.ProjectId != id {
+			milestones = append(milestones, milestone)
+		}
+	}
+	m.milestones = milestones
+
+	timers := []*Timer{}
+	for _, timer := range m.timers {
+		if timer.ProjectId != id {
+			timers = append(timers, timer)
+		}
+	}
+	m.timers = timers
+
+	// The tasks and the sessions go away with the project
+	ids := []uint64{}
+	tasks := []*Task{}
+	for _, task := range m.tasks {
+		if task.ProjectId == id {
+			ids = append(ids, task.Id)
+		} else {
+			tasks = append(tasks, task)
+		}
+	}
+	for _, task := range tasks {
+		for _, taskId := range ids {
+			task.BlockedBy = removeId(task.BlockedBy, taskId)
+		}
+	}
+	m.tasks = tasks
+
+	sessions := []*memorySession{}
+	for _, session := range m.sessions {
+		if session.ProjectId != id {
+			sessions = append(sessions, session)
+		}
+	}
+	m.sessions = sessions
+
+	// The sub-projects are handed over to the parent of the project
+	projects := []*memoryProject{}
+	for _, p := range m.projects {
+		if p.ParentId == id {
+			p.ParentId = project.ParentId
+		}
+		if p.Id != id {
+			projects = append(projects, p)
+		}
+	}
+	m.projects = projects
+	return project.Tags, nil
+}
+
+func (m *MemoryStore) EditProject(id uint64, title, description string,
+	tags []uint64) ([]uint64, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	project := m.findProject(id)
+	if project == nil {
+		return []uint64{}, fmt.Errorf("Unable to find project %d: %s", id, sql.ErrNoRows)
+	}
+
+	for _, p := range m.projects {
+		if p.Id != id && p.Title == title {
+			return []uint64{}, fmt.Errorf("Unable to rename project: %s already exists", title)
+		}
+	}
+	project.Title = title
+	project.Description = description
+
+	newTags, removedTags := getListDifference(project.Tags, tags)
+	for _, tagId := range newTags {
+		project.Tags = insertId(project.Tags, tagId)
+	}
+	for _, tagId := range removedTags {
+		project.Tags = removeId(project.Tags, tagId)
+	}
+	return append(removedTags, newTags...), nil
+}
+
+func (m *MemoryStore) MoveProject(id, parentId uint64) (uint64, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	project := m.findProject(id)
+	if project == nil {
+		return 0, fmt.Errorf("Unable to find project %d: %s", id, sql.ErrNoRows)
+	}
+
+	if parentId != 0 {
+		if m.findProject(parentId) == nil {
+			return 0, fmt.Errorf("Unable to find parent project %d: %s", parentId, sql.ErrNoRows)
+		}
+
+		if inList(parentId, m.projectSubtree(id)) {
+			return 0, fmt.Errorf("Unable to move project: project %d is a sub-project of project %d",
+				parentId, id)
+		}
+	}
+
+	oldParentId := project.ParentId
+	project.ParentId = parentId
+	return oldParentId, nil
+}
+
+func (m *MemoryStore) SetProjectStatus(id uint64, status string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	valid := false
+	for _, s := range projectStatuses {
+		if s == status {
+			valid = true
+		}
+	}
+	if !valid {
+		return fmt.Errorf("Unknown project status: %s", status)
+	}
+
+	project := m.findProject(id)
+	if project == nil {
+		return fmt.Errorf("Unable to find project %d: %s", id, sql.ErrNoRows)
+	}
+
+	if project.Status == status {
+		return nil
+	}
+
+	project.Status = status
+	project.History = append(project.History,
+		StatusChange{status, uint64(time.Now().Unix())})
+	return nil
+}
+
+func (m *MemoryStore) GetProjectById(id uint64) (Project, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	p := m.findProject(id)
+	if p == nil {
+		return Project{}, sql.ErrNoRows
+	}
+
+	project := Project{
+		Id:            p.Id,
+		Title:         p.Title,
+		Description:   p.Description,
+		ParentId:      p.ParentId,
+		Status:        p.Status,
+		StatusHistory: append([]StatusChange{}, p.History...),
+		Tags:          copyIds(p.Tags),
+		Tasks:         m.projectTasks(id),
+		Completeness:  m.completeness(id),
+		Sessions:      []Session{},
+		Milestones:    []Milestone{},
+	}
+	sort.SliceStable(project.StatusHistory, func(i, j int) bool {
+		return project.StatusHistory[i].Date < project.StatusHistory[j].Date
+	})
+
+	for _, milestone := range m.milestones {
+		if milestone.ProjectId == id {
+			project.Milestones = append(project.Milestones, *milestone)
+		}
+	}
+	sort.SliceStable(project.Milestones, func(i, j int) bool {
+		return project.Milestones[i].TargetDate < project.Milestones[j].TargetDate
+	})
+	computeMilestones(project.Milestones, project.Tasks)
+
+	// The time spent on the sub-projects counts towards the totals
+	var durations Durations
+	taskDurations := make(map[uint64]Durations)
+	subtree := m.projectSubtree(id)
+	monthStart, weekStart := m.periods.Bounds(time.Now())
+	for _, session := range m.sessions {
+		if !inList(session.ProjectId, subtree) {
+			continue
+		}
+		dt := time.Unix(int64(session.Date), 0)
+		durations.add(session.Duration, dt, monthStart, weekStart)
+		if session.ProjectId != id {
+			continue
+		}
+
+		project.Sessions = append(project.Sessions, session.Session)
+		if session.TaskId != 0 {
+			d := taskDurations[session.TaskId]
+			d.add(session.Duration, dt, monthStart, weekStart)
+			taskDurations[session.TaskId] = d
+		}
+	}
+	project.DurationTotal = durations.DurationTotal
+	project.DurationMonth = durations.DurationMonth
+	project.DurationWeek = durations.DurationWeek
+
+	for i := range project.Tasks {
+		project.Tasks[i].Durations = taskDurations[project.Tasks[i].Id]
+	}
+
+	project.Goals = m.goalsOf(id, 0, durations)
+	return project, nil
+}
+
+func (m *MemoryStore) GetSummaryById(id uint64) (Summary, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	project := m.findProject(id)
+	if project == nil {
+		return Summary{}, sql.ErrNoRows
+	}
+	return m.summary(project), nil
+}
+
+func (m *MemoryStore) GetSummaryList() ([]Summary, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.summaryList(), nil
+}
+
+func (m *MemoryStore) GetSummaryTree() ([]SummaryNode, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return buildSummaryTree(m.summaryList()), nil
+}
+
+func (m *MemoryStore) GetProjectChildIds(id uint64) ([]uint64, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.projectChildren(id), nil
+}
+
+func (m *MemoryStore) GetProjectAncestorIds(id uint64) ([]uint64, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.projectAncestors(id), nil
+}
+
+//------------------------------------------------------------------------------
+// Tasks
+//------------------------------------------------------------------------------
+
+// Get the ids of the task and all of its descendants, breadth first
+func (m *MemoryStore) taskSubtree(id uint64) []uint64 {
+	subtree := []uint64{id}
+	for i := 0; i < len(subtree); i++ {
+		for _, task := range m.tasks {
+			if task.ParentId == subtree[i] {
+				subtree = append(subtree, task.Id)
+			}
+		}
+	}
+	return subtree
+}
+
+func (m *MemoryStore) addTask(projectId, parentId uint64, details TaskDetails) error {
+	if err := checkTaskDates(details.StartDate, details.DueDate); err != nil {
+		return err
+	}
+
+	if err := checkRecurrence(details.Recurrence, details.RecurrenceInterval); err != nil {
+		return err
+	}
+
+	if parentId != 0 {
+		parent := m.findTask(parentId)
+		if parent == nil {
+			return fmt.Errorf("Unable to find parent task %d: %s", parentId, sql.ErrNoRows)
+		}
+		if parent.ProjectId != projectId {
+			return fmt.Errorf("Parent task %d belongs to a different project", parentId)
+		}
+	}
+
+	var position uint64
+	for _, task := range m.tasks {
+		if task.ProjectId == projectId && task.Position > position {
+			position = task.Position
+		}
+	}
+
+	m.lastTaskId++
+	m.tasks = append(m.tasks, &Task{
+		Id:                 m.lastTaskId,
+		ProjectId:          projectId,
+		ParentId:           parentId,
+		Title:              details.Title,
+		Description:        details.Description,
+		Priority:           uint8(details.Priority),
+		StartDate:          details.StartDate,
+		DueDate:            details.DueDate,
+		Recurrence:         details.Recurrence,
+		RecurrenceInterval: details.RecurrenceInterval,
+		Estimate:           details.Estimate,
+		Position:           position + 1,
+		Tags:               []uint64{},
+		BlockedBy:          []uint64{},
+	})
+	return nil
+}
+
+// Copy the task, its tags, its milestone and its subtasks into the next
+// occurrence under the given parent
+func (m *MemoryStore) copyOccurrence(task *Task, parentId, shift uint64) error {
+	if err := m.addTask(task.ProjectId, parentId, occurrenceDetails(*task, shift)); err != nil {
+		return err
+	}
+	occurrence := m.tasks[len(m.tasks)-1]
+	occurrence.MilestoneId = task.MilestoneId
+	occurrence.Tags = append([]uint64{}, task.Tags...)
+	task.Recurrence = ""
+
+	subtasks := []*Task{}
+	for _, t := range m.tasks {
+		if t.ParentId == task.Id {
+			subtasks = append(subtasks, t)
+		}
+	}
+	sort.SliceStable(subtasks, func(i, j int) bool {
+		return subtasks[i].Position < subtasks[j].Position
+	})
+	for _, subtask := range subtasks {
+		if err := m.copyOccurrence(subtask, occurrence.Id, shift); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *MemoryStore) AddTask(projectId, parentId uint64, details TaskDetails) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.addTask(projectId, parentId, details)
+}
+
+// Delete the task together with all of its subtasks
+func (m *MemoryStore) DeleteTask(id uint64) (uint64, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	task := m.findTask(id)
+	if task == nil {
+		return 0, sql.ErrNoRows
+	}
+
+	ids := m.taskSubtree(id)
+
+	// The logged time stays with the project
+	for _, session := range m.sessions {
+		if inList(session.TaskId, ids) {
+			session.TaskId = 0
+		}
+	}
+
+	tasks := []*Task{}
+	for _, t := range m.tasks {
+		if inList(t.Id, ids) {
+			continue
+		}
+		for _, taskId := range ids {
+			t.BlockedBy = removeId(t.BlockedBy, taskId)
+		}
+		tasks = append(tasks, t)
+	}
+	m.tasks = tasks
+	return task.ProjectId, nil
+}
+
+func (m *MemoryStore) EditTask(id uint64, details TaskDetails) (uint64, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if err := checkTaskDates(details.StartDate, details.DueDate); err != nil {
+		return 0, err
+	}
+
+	if err := checkRecurrence(details.Recurrence, details.RecurrenceInterval); err != nil {
+		return 0, err
+	}
+
+	task := m.findTask(id)
+	if task == nil {
+		return 0, fmt.Errorf("Unable get projectId for task: %s", sql.ErrNoRows)
+	}
+
+	task.Title = details.Title
+	task.Description = details.Description
+	task.Priority = uint8(details.Priority)
+	task.StartDate = details.StartDate
+	task.DueDate = details.DueDate
+	task.Recurrence = details.Recurrence
+	task.RecurrenceInterval = details.RecurrenceInterval
+	task.Estimate = details.Estimate
+	return task.ProjectId, nil
+}
+
+// Toggle the status of the task; if cascade is set, all the subtasks are
+// given the same status as their ancestor
+func (m *MemoryStore) ToggleTask(id uint64, cascade bool) (uint64, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	task := m.findTask(id)
+	if task == nil {
+		return 0, fmt.Errorf("Unable to get project id for task %s", sql.ErrNoRows)
+	}
+
+	status := task.Done
+	ids := []uint64{id}
+	if cascade {
+		ids = m.taskSubtree(id)
+	}
+
+	for _, taskId := range ids {
+		m.findTask(taskId).Done = !status
+	}
+
+	if !status && task.Recurrence != "" {
+		err := m.copyOccurrence(task, task.ParentId, occurrenceShift(*task))
+		if err != nil {
+			return 0, fmt.Errorf("Unable to schedule the next occurrence of task %d: %s",
+				id, err)
+		}
+	}
+	return task.ProjectId, nil
+}
+
+// Move the task just before or just after the target task
+func (m *MemoryStore) ReorderTask(id, targetId uint64, after bool) (uint64, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	task := m.findTask(id)
+	if task == nil {
+		return 0, fmt.Errorf("Unable to find task %d: %s", id, sql.ErrNoRows)
+	}
+
+	target := m.findTask(targetId)
+	if target == nil {
+		return 0, fmt.Errorf("Unable to find task %d: %s", targetId, sql.ErrNoRows)
+	}
+
+	if task.ProjectId != target.ProjectId {
+		return 0, fmt.Errorf("Cannot reorder tasks belonging to different projects")
+	}
+
+	if id == targetId {
+		return task.ProjectId, nil
+	}
+
+	order := []uint64{}
+	for _, t := range m.projectTasks(task.ProjectId) {
+		if t.Id == id {
+			continue
+		}
+		if t.Id == targetId && !after {
+			order = append(order, id)
+		}
+		order = append(order, t.Id)
+		if t.Id == targetId && after {
+			order = append(order, id)
+		}
+	}
+
+	for position, taskId := range order {
+		m.findTask(taskId).Position = uint64(position + 1)
+	}
+	return task.ProjectId, nil
+}
+
+func (m *MemoryStore) AddTaskTag(taskId, tagId uint64) (uint64, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	task := m.findTask(taskId)
+	if task == nil {
+		return 0, fmt.Errorf("Unable to find task %d: %s", taskId, sql.ErrNoRows)
+	}
+
+	if m.findTag(tagId) == nil {
+		return 0, fmt.Errorf("Unable to find tag %d: %s", tagId, sql.ErrNoRows)
+	}
+
+	task.Tags = insertId(task.Tags, tagId)
+	return task.ProjectId, nil
+}
+
+func (m *MemoryStore) RemoveTaskTag(taskId, tagId uint64) (uint64, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	task := m.findTask(taskId)
+	if task == nil {
+		return 0, fmt.Errorf("Unable to find task %d: %s", taskId, sql.ErrNoRows)
+	}
+
+	task.Tags = removeId(task.Tags, tagId)
+	return task.ProjectId, nil
+}
+
+// Mark the task as blocked by another task; returns the id of the project
+// of the blocked task
+func (m *MemoryStore) LinkTasks(taskId, blockerId uint64) (uint64, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if taskId == blockerId {
+		return 0, fmt.Errorf("A task cannot block itself")
+	}
+
+	task := m.findTask(taskId)
+	if task == nil {
+		return 0, fmt.Errorf("Unable to find task %d: %s", taskId, sql.ErrNoRows)
+	}
+
+	if m.findTask(blockerId) == nil {
+		return 0, fmt.Errorf("Unable to find task %d: %s", blockerId, sql.ErrNoRows)
+	}
+
+	// The new link closes a cycle if the task already blocks the blocker,
+	// directly or indirectly
+	blockers := []uint64{blockerId}
+	for i := 0; i < len(blockers); i++ {
+		t := m.findTask(blockers[i])
+		if t == nil {
+			continue
+		}
+		for _, id := range t.BlockedBy {
+			if id == taskId {
+				return 0, fmt.Errorf("Unable to link tasks: task %d already depends on task %d",
+					blockerId, taskId)
+			}
+			if !inList(id, blockers) {
+				blockers = append(blockers, id)
+			}
+		}
+	}
+
+	task.BlockedBy = insertId(task.BlockedBy, blockerId)
+	return task.ProjectId, nil
+}
+
+func (m *MemoryStore) UnlinkTasks(taskId, blockerId uint64) (uint64, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	task := m.findTask(taskId)
+	if task == nil {
+		return 0, fmt.Errorf("Unable to find task %d: %s", taskId, sql.ErrNoRows)
+	}
+
+	task.BlockedBy = removeId(task.BlockedBy, blockerId)
+	return task.ProjectId, nil
+}
+
+// Get the ids of the tags associated with the task or any of its subtasks
+func (m *MemoryStore) GetSubtaskTagIds(id uint64) ([]uint64, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	tagIds := []uint64{}
+	for _, taskId := range m.taskSubtree(id) {
+		if task := m.findTask(taskId); task != nil {
+			for _, tagId := range task.Tags {
+				if !inList(tagId, tagIds) {
+					tagIds = append(tagIds, tagId)
+				}
+			}
+		}
+	}
+	return tagIds, nil
+}
+
+// Get the ids of the projects containing tasks blocked by the given task or
+// any of its subtasks
+func (m *MemoryStore) GetDependentProjectIds(id uint64) ([]uint64, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	subtree := m.taskSubtree(id)
+	projectIds := []uint64{}
+	for _, task := range m.tasks {
+		for _, blockerId := range task.BlockedBy {
+			if inList(blockerId, subtree) && !inList(task.ProjectId, projectIds) {
+				projectIds = append(projectIds, task.ProjectId)
+			}
+		}
+	}
+	return projectIds, nil
+}
+
+// Get the unfinished tasks that are past their due date and the ones that
+// are due within the given number of days
+func (m *MemoryStore) GetDueTasks(days uint64) (DueTasks, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	now := uint64(time.Now().Unix())
+	horizon := uint64(time.Now().AddDate(0, 0, int(days)).Unix())
+	dueTasks := DueTasks{Overdue: []Task{}, Upcoming: []Task{}}
+
+	for _, t := range m.tasks {
+		if t.Done || t.DueDate == 0 || t.DueDate >= horizon {
+			continue
+		}
+		if t.DueDate < now {
+			dueTasks.Overdue = append(dueTasks.Overdue, m.task(t))
+		} else {
+			dueTasks.Upcoming = append(dueTasks.Upcoming, m.task(t))
+		}
+	}
+
+	for _, tasks := range [][]Task{dueTasks.Overdue, dueTasks.Upcoming} {
+		tasks := tasks
+		sort.SliceStable(tasks, func(i, j int) bool {
+			return tasks[i].DueDate < tasks[j].DueDate
+		})
+	}
+	return dueTasks, nil
+}
+
+//------------------------------------------------------------------------------
+// Milestones and goals
+//------------------------------------------------------------------------------
+
+func (m *MemoryStore) CreateMilestone(projectId uint64, name string, targetDate uint64) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.findProject(projectId) == nil {
+		return fmt.Errorf("Unable to find project %d: %s", projectId, sql.ErrNoRows)
+	}
+
+	m.lastMilestoneId++
+	m.milestones = append(m.milestones, &Milestone{
+		Id:         m.lastMilestoneId,
+		ProjectId:  projectId,
+		Name:       name,
+		TargetDate: targetDate,
+	})
+	return nil
+}
+
+func (m *MemoryStore) EditMilestone(id uint64, name string, targetDate uint64) (uint64, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	milestone := m.findMilestone(id)
+	if milestone == nil {
+		return 0, fmt.Errorf("Unable to find milestone %d: %s", id, sql.ErrNoRows)
+	}
+
+	milestone.Name = name
+	milestone.TargetDate = targetDate
+	return milestone.ProjectId, nil
+}
+
+func (m *MemoryStore) DeleteMilestone(id uint64) (uint64, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	milestone := m.findMilestone(id)
+	if milestone == nil {
+		return 0, fmt.Errorf("Unable to find milestone %d: %s", id, sql.ErrNoRows)
+	}
+
+	for _, task := range m.tasks {
+		if task.MilestoneId == id {
+			task.MilestoneId = 0
+		}
+	}
+
+	milestones := []*Milestone{}
+	for _, ms := range m.milestones {
+		if ms.Id != id {
+			milestones = append(milestones, ms)
+		}
+	}
+	m.milestones = milestones
+	return milestone.ProjectId, nil
+}
+
+// Assign the task to a milestone of its project, or unassign it if the
+// milestone id is zero
+func (m *MemoryStore) AssignMilestone(taskId, milestoneId uint64) (uint64, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	task := m.findTask(taskId)
+	if task == nil {
+		return 0, fmt.Errorf("Unable to find task %d: %s", taskId, sql.ErrNoRows)
+	}
+
+	if milestoneId != 0 {
+		milestone := m.findMilestone(milestoneId)
+		if milestone == nil {
+			return 0, fmt.Errorf("Unable to find milestone %d: %s", milestoneId, sql.ErrNoRows)
+		}
+		if milestone.ProjectId != task.ProjectId {
+			return 0, fmt.Errorf("Milestone %d belongs to a different project", milestoneId)
+		}
+	}
+
+	task.MilestoneId = milestoneId
+	return task.ProjectId, nil
+}
+
+func (m *MemoryStore) CreateGoal(projectId, tagId uint64, period string,
+	minimum, maximum uint64) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if (projectId == 0) == (tagId == 0) {
+		return fmt.Errorf("A goal needs either a project or a tag")
+	}
+
+	if err := checkGoal(period, minimum, maximum); err != nil {
+		return err
+	}
+
+	if projectId != 0 && m.findProject(projectId) == nil {
+		return fmt.Errorf("Unable to find project %d: %s", projectId, sql.ErrNoRows)
+	}
+	if tagId != 0 && m.findTag(tagId) == nil {
+		return fmt.Errorf("Unable to find tag %d: %s", tagId, sql.ErrNoRows)
+	}
+
+	m.lastGoalId++
+	m.goals = append(m.goals, &Goal{
+		Id:        m.lastGoalId,
+		ProjectId: projectId,
+		TagId:     tagId,
+		Period:    period,
+		Minimum:   minimum,
+		Maximum:   maximum,
+	})
+	return nil
+}
+
+func (m *MemoryStore) EditGoal(id uint64, period string,
+	minimum, maximum uint64) (uint64, uint64, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	goal := m.findGoal(id)
+	if goal == nil {
+		return 0, 0, fmt.Errorf("Unable to find goal %d: %s", id, sql.ErrNoRows)
+	}
+
+	if err := checkGoal(period, minimum, maximum); err != nil {
+		return 0, 0, err
+	}
+
+	goal.Period = period
+	goal.Minimum = minimum
+	goal.Maximum = maximum
+	return goal.ProjectId, goal.TagId, nil
+}
+
+func (m *MemoryStore) DeleteGoal(id uint64) (uint64, uint64, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	goal := m.findGoal(id)
+	if goal == nil {
+		return 0, 0, fmt.Errorf("Unable to find goal %d: %s", id, sql.ErrNoRows)
+	}
+
+	goals := []*Goal{}
+	for _, g := range m.goals {
+		if g.Id != id {
+			goals = append(goals, g)
+		}
+	}
+	m.goals = goals
+	return goal.ProjectId, goal.TagId, nil
+}
+
+//------------------------------------------------------------------------------
+// Sessions and timers
+//------------------------------------------------------------------------------
+
+func (m *MemoryStore) checkSessionTask(projectId, taskId uint64) error {
+	if taskId == 0 {
+		return nil
+	}
+
+	task := m.findTask(taskId)
+	if task == nil {
+		return fmt.Errorf("Unable to find task %d: %s", taskId, sql.ErrNoRows)
+	}
+	if task.ProjectId != projectId {
+		return fmt.Errorf("Task %d belongs to a different project", taskId)
+	}
+	return nil
+}
+
+func (m *MemoryStore) addSession(projectId, taskId, duration, date uint64, note string) error {
+	if err := m.checkSessionTask(projectId, taskId); err != nil {
+		return err
+	}
+
+	m.lastSessionId++
+	m.sessions = append(m.sessions, &memorySession{
+		Session{m.lastSessionId, taskId, duration, date, note}, projectId})
+	return nil
+}
+
+func (m *MemoryStore) AddSession(projectId, taskId, duration, date uint64, note string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.addSession(projectId, taskId, duration, date, note)
+}
+
+func (m *MemoryStore) EditSession(id, taskId, duration, date uint64,
+	note string) (uint64, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	session := m.findSession(id)
+	if session == nil {
+		return 0, fmt.Errorf("Unable to get project id for session %s", sql.ErrNoRows)
+	}
+
+	if err := m.checkSessionTask(session.ProjectId, taskId); err != nil {
+		return 0, err
+	}
+
+	session.TaskId = taskId
+	session.Date = date
+	session.Duration = duration
+	session.Note = note
+	return session.ProjectId, nil
+}
+
+func (m *MemoryStore) DeleteSession(id uint64) (uint64, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	session := m.findSession(id)
+	if session == nil {
+		return 0, fmt.Errorf("Unable to get project id for session %s", sql.ErrNoRows)
+	}
+
+	sessions := []*memorySession{}
+	for _, s := range m.sessions {
+		if s.Id != id {
+			sessions = append(sessions, s)
+		}
+	}
+	m.sessions = sessions
+	return session.ProjectId, nil
+}
+
+func (m *MemoryStore) GetTimer(projectId uint64) (Timer, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if timer := m.findTimer(projectId); timer != nil {
+		return *timer, nil
+	}
+	return Timer{}, sql.ErrNoRows
+}
+
+func (m *MemoryStore) GetTimerList() ([]Timer, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	timers := []Timer{}
+	for _, timer := range m.timers {
+		timers = append(timers, *timer)
+	}
+	return timers, nil
+}
+
+// Start a new timer for the project or resume a paused one
+func (m *MemoryStore) StartTimer(projectId uint64) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	now := uint64(time.Now().Unix())
+	timer := m.findTimer(projectId)
+	if timer == nil {
+		// The timers are kept in the order of their projects
+		m.timers = append(m.timers, &Timer{projectId, now, now, 0, true})
+		sort.Slice(m.timers, func(i, j int) bool {
+			return m.timers[i].ProjectId < m.timers[j].ProjectId
+		})
+		return nil
+	}
+
+	if timer.Running {
+		return fmt.Errorf("Timer for project %d is already running", projectId)
+	}
+
+	timer.Resumed = now
+	timer.Running = true
+	return nil
+}
+
+func (m *MemoryStore) PauseTimer(projectId uint64) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	timer := m.findTimer(projectId)
+	if timer == nil {
+		return fmt.Errorf("No timer is running for project %d", projectId)
+	}
+
+	if !timer.Running {
+		return fmt.Errorf("Timer for project %d is already paused", projectId)
+	}
+
+	timer.Elapsed = timer.ElapsedAt(time.Now())
+	timer.Running = false
+	return nil
+}
+
+// Stop the timer and record the time it measured as a session; returns
+// the duration of the session in minutes
+func (m *MemoryStore) StopTimer(projectId uint64) (uint64, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	timer := m.findTimer(projectId)
+	if timer == nil {
+		return 0, fmt.Errorf("No timer is running for project %d", projectId)
+	}
+
+	duration := (timer.ElapsedAt(time.Now()) + 30) / 60
+	if duration != 0 {
+		if err := m.addSession(projectId, 0, duration, timer.Started, ""); err != nil {
+			return 0, err
+		}
+	}
+
+	timers := []*Timer{}
+	for _, t := range m.timers {
+		if t.ProjectId != projectId {
+			timers = append(timers, t)
+		}
+	}
+	m.timers = timers
+	return duration, nil
+}
+
+//------------------------------------------------------------------------------
+// Reports
+//------------------------------------------------------------------------------
+
+// Compare the estimated and the logged time of the tasks of a project or of
+// all the projects if the id is zero
+func (m *MemoryStore) GetEstimateReport(projectId uint64) ([]ProjectEstimate, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	reports := []ProjectEstimate{}
+	for _, project := range m.projects {
+		if projectId != 0 && project.Id != projectId {
+			continue
+		}
+
+		report := ProjectEstimate{ProjectId: project.Id, Title: project.Title,
+			Tasks: []TaskEstimate{}}
+		for _, session := range m.sessions {
+			if session.ProjectId != project.Id {
+				continue
+			}
+			report.Logged += session.Duration
+			if session.TaskId == 0 {
+				report.Unassigned += session.Duration
+			}
+		}
+
+		for _, task := range m.tasks {
+			if task.ProjectId != project.Id {
+				continue
+			}
+			estimate := TaskEstimate{TaskId: task.Id, Title: task.Title, Done: task.Done,
+				Estimate: task.Estimate}
+			for _, session := range m.sessions {
+				if session.TaskId == task.Id {
+					estimate.Logged += session.Duration
+				}
+			}
+			if estimate.Estimate != 0 || estimate.Logged != 0 {
+				report.Estimate += estimate.Estimate
+				report.Tasks = append(report.Tasks, estimate)
+			}
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+// Get the time logged in the [from, to) range, in total and per project; the
+// range can also be given as a calendar period offset from the current one
+func (m *MemoryStore) GetRangeReport(from, to uint64, period string,
+	offset int) (RangeReport, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	from, to, err := m.periods.resolveRange(from, to, period, offset)
+	if err != nil {
+		return RangeReport{}, err
+	}
+
+	report := RangeReport{From: from, To: to, Projects: []ProjectTotal{}}
+	for _, project := range m.projects {
+		total := ProjectTotal{ProjectId: project.Id, Title: project.Title}
+		logged := false
+		for _, session := range m.sessions {
+			if session.ProjectId == project.Id && session.Date >= from && session.Date < to {
+				total.Duration += session.Duration
+				logged = true
+			}
+		}
+		if logged {
+			report.DurationTotal += total.Duration
+			report.Projects = append(report.Projects, total)
+		}
+	}
+	sort.SliceStable(report.Projects, func(i, j int) bool {
+		return report.Projects[i].Title < report.Projects[j].Title
+	})
+	return report, nil
+}
+
+// Aggregate the time logged in the [from, to) range the same way as the
+// SQL query of the database does
+func (m *MemoryStore) QueryReport(from, to uint64, period string, offset int,
+	projectIds, tagIds []uint64, groupBy string) (Report, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	from, to, err := m.periods.resolveRange(from, to, period, offset)
+	if err != nil {
+		return Report{}, err
+	}
+
+	// The filters include the descendants of the given projects and tags
+	var projectFilter []uint64
+	for _, id := range projectIds {
+		if m.findProject(id) != nil {
+			projectFilter = append(projectFilter, m.projectSubtree(id)...)
+		}
+	}
+
+	var tagFilter []uint64
+	for _, id := range tagIds {
+		if m.findTag(id) != nil {
+			tagFilter = append(tagFilter, m.tagSubtree(id)...)
+		}
+	}
+
+	selected := []*memorySession{}
+	for _, session := range m.sessions {
+		if session.Date < from || session.Date >= to {
+			continue
+		}
+		if len(projectIds) != 0 && !inList(session.ProjectId, projectFilter) {
+			continue
+		}
+		project := m.findProject(session.ProjectId)
+		if project == nil {
+			continue
+		}
+		if len(tagIds) != 0 {
+			tagged := false
+			for _, tagId := range project.Tags {
+				tagged = tagged || inList(tagId, tagFilter)
+			}
+			if !tagged {
+				continue
+			}
+		}
+		selected = append(selected, session)
+	}
+
+	report := Report{From: from, To: to, GroupBy: groupBy, Groups: []ReportGroup{}}
+	for _, session := range selected {
+		report.DurationTotal += session.Duration
+		report.NumberOfSessions++
+	}
+
+	groups := make(map[ReportGroup]int)
+	addToGroup := func(id uint64, label string, session *memorySession) {
+		key := ReportGroup{Id: id, Label: label}
+		idx, ok := groups[key]
+		if !ok {
+			idx = len(report.Groups)
+			groups[key] = idx
+			report.Groups = append(report.Groups, key)
+		}
+		report.Groups[idx].Duration += session.Duration
+		report.Groups[idx].NumberOfSessions++
+	}
+
+	timeBuckets := groupBy == GroupByDay || groupBy == GroupByWeek || groupBy == GroupByMonth
+	if !timeBuckets && groupBy != GroupByProject && groupBy != GroupByTag {
+		return Report{}, fmt.Errorf("Unknown report grouping: %s", groupBy)
+	}
+
+	for _, session := range selected {
+		project := m.findProject(session.ProjectId)
+		if project == nil {
+			continue
+		}
+
+		local := time.Unix(int64(session.Date), 0).In(m.periods.Location)
+		switch groupBy {
+		case GroupByDay:
+			addToGroup(0, local.Format("2006-01-02"), session)
+		case GroupByWeek:
+			shift := (int(local.Weekday()) + 7 - int(m.periods.WeekStart)) % 7
+			addToGroup(0, local.AddDate(0, 0, -shift).Format("2006-01-02"), session)
+		case GroupByMonth:
+			addToGroup(0, local.Format("2006-01")+"-01", session)
+		case GroupByProject:
+			addToGroup(project.Id, project.Title, session)
+		case GroupByTag:
+			// A session counts towards every tag of its project, or only the ones
+			// selected by the filter
+			tags := project.Tags
+			if len(tagIds) != 0 {
+				tags = []uint64{}
+				for _, tagId := range project.Tags {
+					if inList(tagId, tagFilter) {
+						tags = append(tags, tagId)
+					}
+				}
+			}
+			if len(tags) == 0 {
+				addToGroup(0, "", session)
+			}
+			for _, tagId := range tags {
+				if tag := m.findTag(tagId); tag != nil {
+					addToGroup(tag.Id, tag.Name, session)
+				} else {
+					addToGroup(0, "", session)
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(report.Groups, func(i, j int) bool {
+		return report.Groups[i].Label < report.Groups[j].Label
+	})
+
+	if timeBuckets {
+		for i := range report.Groups {
+			start, err := time.ParseInLocation("2006-01-02", report.Groups[i].Label,
+				m.periods.Location)
+			if err != nil {
+				return Report{}, fmt.Errorf("Unable to parse the report date: %s", err)
+			}
+			report.Groups[i].Start = uint64(start.Unix())
+		}
+	}
+	return report, nil
+}
+
+// Run fn with the store locked, so that the other writers wait for the batch,
+// and restore the state from before it if it fails; fn must use the store it
+// is given, which shares the state but doesn't lock it again
+func (m *MemoryStore) Batch(fn func(store Store) error) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	saved := m.memoryState.clone()
+	view := &MemoryStore{mutex: noLock{}, periods: m.periods, memoryState: m.memoryState}
+	if err := fn(view); err != nil {
+		*m.memoryState = saved
+		return err
+	}
+	return nil
+}
+
+func NewMemoryStore(opts *BackendOpts) (*MemoryStore, error) {
+	periods, err := NewPeriods(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	m := new(MemoryStore)
+	m.mutex = new(sync.Mutex)
+	m.periods = periods
+	m.memoryState = new(memoryState)
+	return m, nil
+}
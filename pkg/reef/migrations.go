@@ -0,0 +1,203 @@
+//------------------------------------------------------------------------------
+// Author: Lukasz Janyst <lukasz@jany.st>
+// Date: 17.10.2026
+//
+// Licensed under the GPL 3 License, see the LICENSE file for details.
+//------------------------------------------------------------------------------
+
+package reef
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// A numbered change of the database schema; the up steps bring the schema
+// from the previous version to this one and the down steps bring it back
+type Migration struct {
+	Version uint64
+	Name    string
+	Up      []CommandEntry
+	Down    []CommandEntry // Nil if the migration cannot be reverted
+}
+
+type migrationRegistry struct {
+	// Schema of the latest version created in one go for new databases; they
+	// run all the migrations if it's not there
+	schema     []CommandEntry
+	migrations []Migration
+}
+
+func (r *migrationRegistry) latest() uint64 {
+	return r.migrations[len(r.migrations)-1].Version
+}
+
+func (r *migrationRegistry) find(version uint64) (Migration, bool) {
+	for _, migration := range r.migrations {
+		if migration.Version == version {
+			return migration, true
+		}
+	}
+	return Migration{}, false
+}
+
+type MigrationStatus struct {
+	Version    uint64
+	Name       string
+	Applied    bool
+	Reversible bool
+	AppliedAt  uint64 // Zero if the history does not know when it happened
+}
+
+// Get the version of the schema; zero for new databases
+func (db *Database) GetSchemaVersion() (uint64, error) {
+	md, err := db.readMetadata()
+	if err != nil {
+		return 0, err
+	}
+	versionStr, ok := md["version"]
+	if !ok {
+		return 0, nil
+	}
+	version, err := strconv.ParseUint(versionStr, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Unable to parse the version string: %s", err)
+	}
+	return version, nil
+}
+
+func (db *Database) GetLatestSchemaVersion() uint64 {
+	return db.migrations.latest()
+}
+
+func (db *Database) GetMigrationStatus() ([]MigrationStatus, error) {
+	version, err := db.GetSchemaVersion()
+	if err != nil {
+		return []MigrationStatus{}, err
+	}
+
+	statuses := []MigrationStatus{}
+	for _, migration := range db.migrations.migrations {
+		status := MigrationStatus{
+			Version:    migration.Version,
+			Name:       migration.Name,
+			Applied:    migration.Version <= version,
+			Reversible: migration.Down != nil,
+		}
+		if status.Applied {
+			query := "SELECT COALESCE(MAX(timestamp), 0) FROM migrationHistory " +
+				"WHERE (direction = 'up' AND version = ?) " +
+				"OR (direction = 'create' AND version >= ?);"
+			err := db.db.QueryRow(query, migration.Version, migration.Version).Scan(
+				&status.AppliedAt)
+			if err != nil {
+				return []MigrationStatus{}, fmt.Errorf("Unable to query the migration history: %s",
+					err)
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Record the new version of the schema together with what brought it there
+func (db *Database) setSchemaVersion(version uint64, direction string) error {
+	query := "INSERT INTO metadata (key, value) VALUES ('version', ?) " +
+		"ON CONFLICT (key) DO UPDATE SET value = excluded.value;"
+	if _, err := db.db.Exec(query, strconv.FormatUint(version, 10)); err != nil {
+		return fmt.Errorf("Unable to update the database version: %s", err)
+	}
+
+	query = "INSERT INTO migrationHistory (version, direction, timestamp) VALUES (?, ?, ?);"
+	if _, err := db.db.Exec(query, version, direction, time.Now().Unix()); err != nil {
+		return fmt.Errorf("Unable to update the migration history: %s", err)
+	}
+	return nil
+}
+
+// Apply or revert the migrations one by one, each in its own transaction,
+// until the schema reaches the target version
+func (db *Database) migrate(version, target uint64) error {
+	if version == 0 && db.migrations.schema != nil {
+		log.Info("Creating a new database")
+		version = db.migrations.latest()
+		err := db.transaction(func(tx *Database) error {
+			if err := executeQueries(tx.db, db.migrations.schema); err != nil {
+				return err
+			}
+			return tx.setSchemaVersion(version, "create")
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for ; version < target; version++ {
+		migration, ok := db.migrations.find(version + 1)
+		if !ok {
+			return fmt.Errorf("Cannot find the migration to version %d", version+1)
+		}
+		log.Infof("Applying migration %d: %s", migration.Version, migration.Name)
+		err := db.transaction(func(tx *Database) error {
+			if err := executeQueries(tx.db, migration.Up); err != nil {
+				return err
+			}
+			return tx.setSchemaVersion(migration.Version, "up")
+		})
+		if err != nil {
+			return fmt.Errorf("Migration to version %d failed: %s", migration.Version, err)
+		}
+	}
+
+	for ; version > target; version-- {
+		migration, ok := db.migrations.find(version)
+		if !ok || migration.Down == nil {
+			return fmt.Errorf("Migration %d cannot be reverted", version)
+		}
+		log.Infof("Reverting migration %d: %s", migration.Version, migration.Name)
+		err := db.transaction(func(tx *Database) error {
+			if err := executeQueries(tx.db, migration.Down); err != nil {
+				return err
+			}
+			return tx.setSchemaVersion(migration.Version-1, "down")
+		})
+		if err != nil {
+			return fmt.Errorf("Reverting migration %d failed: %s", migration.Version, err)
+		}
+	}
+	return nil
+}
+
+// Bring the schema to the target version; the SQLite database file is
+// backed up before any existing schema is touched
+func (db *Database) Migrate(target uint64) error {
+	version, err := db.GetSchemaVersion()
+	if err != nil {
+		return err
+	}
+	if target > db.migrations.latest() {
+		return fmt.Errorf("Unknown database version: %d", target)
+	}
+	if version > db.migrations.latest() {
+		return fmt.Errorf("Database version %d is newer than the supported version %d",
+			version, db.migrations.latest())
+	}
+
+	for v := target + 1; v <= version; v++ {
+		if migration, ok := db.migrations.find(v); !ok || migration.Down == nil {
+			return fmt.Errorf("Migration %d cannot be reverted", v)
+		}
+	}
+
+	log.Info("Database version: ", version)
+	if version == target {
+		return nil
+	}
+	if db.dir != "" && version != 0 {
+		return db.upgrade(db.dir, version, target)
+	}
+	return db.migrate(version, target)
+}
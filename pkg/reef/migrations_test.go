@@ -0,0 +1,162 @@
+//------------------------------------------------------------------------------
+// Author: Lukasz Janyst <lukasz@jany.st>
+// Date: 17.10.2026
+//
+// Licensed under the GPL 3 License, see the LICENSE file for details.
+//------------------------------------------------------------------------------
+
+package reef
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Describe the columns and the foreign keys of all the tables
+func dumpSchema(t *testing.T, db *Database) string {
+	rows, err := db.db.Query("SELECT name FROM sqlite_master WHERE type = 'table' " +
+		"AND name != 'sqlite_sequence' ORDER BY name;")
+	if err != nil {
+		t.Fatalf("Unable to list the tables: %s", err)
+	}
+	tables := []string{}
+	for rows.Next() {
+		var table string
+		rows.Scan(&table)
+		tables = append(tables, table)
+	}
+	rows.Close()
+
+	var b strings.Builder
+	for _, table := range tables {
+		for _, pragma := range []string{"table_info", "foreign_key_list"} {
+			rows, err := db.db.Query(fmt.Sprintf("PRAGMA %s(%s);", pragma, table))
+			if err != nil {
+				t.Fatalf("Unable to describe %s: %s", table, err)
+			}
+			columns, _ := rows.Columns()
+			values := make([]interface{}, len(columns))
+			pointers := make([]interface{}, len(columns))
+			for i := range values {
+				pointers[i] = &values[i]
+			}
+			for rows.Next() {
+				rows.Scan(pointers...)
+				fmt.Fprintf(&b, "%s %s %v\n", table, pragma, values)
+			}
+			rows.Close()
+		}
+	}
+	return b.String()
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "reef-migrations")
+	if err != nil {
+		t.Fatalf("Unable to create a temporary directory: %s", err)
+	}
+	return dir
+}
+
+func TestMigrations(t *testing.T) {
+	freshDir := tempDir(t)
+	defer os.RemoveAll(freshDir)
+	fresh, err := NewDatabase(&BackendOpts{DatabaseDirectory: freshDir})
+	if err != nil {
+		t.Fatalf("Unable to create the database: %s", err)
+	}
+	defer fresh.db.Close()
+
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	db := newFaultyDatabase(t, dir)
+	defer func() { db.db.Close() }()
+	if err := db.SetProjectStatus(2, ProjectPaused); err != nil {
+		t.Fatalf("Unable to pause the project: %s", err)
+	}
+	// Takes the name of a built-in tag that comes back with the old schema
+	if _, err := db.CreateTag("Limbo", "#000000", 0); err != nil {
+		t.Fatalf("Unable to create the tag: %s", err)
+	}
+
+	// Every failure while reverting the migrations leaves the database as it was
+	latest := db.GetLatestSchemaVersion()
+	for version := latest; version > 3; version-- {
+		before := dumpSchema(t, db)
+		for n := 1; ; n++ {
+			faulty.arm(n)
+			err := db.migrate(version, version-1)
+			injected := faulty.executed() >= n
+			faulty.arm(0)
+			if !injected {
+				if err != nil {
+					t.Fatalf("%s", err)
+				}
+				break
+			}
+			if err == nil {
+				t.Fatalf("Reverting migration %d ignored the failure of statement %d", version, n)
+			}
+			if after := dumpSchema(t, db); after != before {
+				t.Fatalf("Reverting migration %d left changes behind after the failure of "+
+					"statement %d", version, n)
+			}
+		}
+	}
+
+	if err := db.Migrate(2); err == nil {
+		t.Errorf("Reverted an irreversible migration")
+	}
+
+	// Going back up backs up the database file and ends with the same schema as
+	// a new database
+	if err := db.Migrate(latest); err != nil {
+		t.Fatalf("Unable to migrate the database: %s", err)
+	}
+	if actual, expected := dumpSchema(t, db), dumpSchema(t, fresh); actual != expected {
+		t.Errorf("Wrong schema after the migrations:\n%s\nExpected:\n%s", actual, expected)
+	}
+	backups, _ := filepath.Glob(filepath.Join(dir, "reef.db-*-version-3"))
+	if len(backups) != 1 {
+		t.Errorf("Wrong backups: %v", backups)
+	}
+
+	project, err := db.GetProjectById(2)
+	if err != nil {
+		t.Fatalf("Unable to get the project: %s", err)
+	}
+	if project.Status != ProjectPaused {
+		t.Errorf("Wrong project status after the migrations: %s", project.Status)
+	}
+	tags, err := db.GetTagList()
+	if err != nil || len(tags) != 4 || tags[3].Name != "Limbo (renamed)" {
+		t.Errorf("Wrong tags after the migrations: %v, %v", tags, err)
+	}
+	tasks, err := db.GetProjectTasks(1)
+	if err != nil || len(tasks) != 3 {
+		t.Errorf("Wrong tasks after the migrations: %v, %s", tasks, err)
+	}
+
+	statuses, err := db.GetMigrationStatus()
+	if err != nil {
+		t.Fatalf("Unable to get the migration status: %s", err)
+	}
+	for _, status := range statuses {
+		if !status.Applied || status.AppliedAt == 0 {
+			t.Errorf("Wrong status of migration %d: %v", status.Version, status)
+		}
+		if status.Reversible != (status.Version > 3) {
+			t.Errorf("Wrong reversibility of migration %d", status.Version)
+		}
+	}
+
+	var history int
+	query := "SELECT COUNT(*) FROM migrationHistory WHERE direction = 'down';"
+	if err := db.db.QueryRow(query).Scan(&history); err != nil || history != int(latest-3) {
+		t.Errorf("Wrong migration history: %d, %v", history, err)
+	}
+}
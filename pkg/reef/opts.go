@@ -33,7 +33,13 @@ type WebOpts struct {
 }
 
 type BackendOpts struct {
-	DatabaseDirectory string // Directory for the database files
+	Driver            string // Database engine, sqlite3 (default) or postgres
+	DSN               string // Connection string of the postgres database
+	DatabaseDirectory string // Directory for the sqlite3 database files
+	WeekStart         string // First day of the week, ie. Monday or Sunday
+	TimeZone          string // IANA time zone of the reporting periods
+	CalendarPeriods   bool   // Use calendar weeks and months instead of rolling ones
+	FiscalYearStart   int    // First month of the fiscal year, 1 to 12
 }
 
 type ReefOpts struct {
@@ -46,7 +52,11 @@ func NewReefOpts() (opts *ReefOpts) {
 	opts = new(ReefOpts)
 	opts.Web.BindAddresses = []BindAddress{BindAddress{"localhost", 7651, false}}
 	opts.Web.EnableAuth = false
+	opts.Backend.Driver = "sqlite3"
 	opts.Backend.DatabaseDirectory = "data"
+	opts.Backend.WeekStart = "Monday"
+	opts.Backend.TimeZone = "Local"
+	opts.Backend.FiscalYearStart = 1
 	return
 }
 
@@ -0,0 +1,120 @@
+//------------------------------------------------------------------------------
+// Author: Lukasz Janyst <lukasz@jany.st>
+// Date: 17.10.2026
+//
+// Licensed under the GPL 3 License, see the LICENSE file for details.
+//------------------------------------------------------------------------------
+
+package reef
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Reporting periods; rolling periods end now, calendar periods start at the
+// beginning of the current week, month or quarter in the configured time zone
+type Periods struct {
+	WeekStart       time.Weekday
+	Location        *time.Location
+	Calendar        bool
+	FiscalYearStart time.Month
+}
+
+func NewPeriods(opts *BackendOpts) (Periods, error) {
+	periods := Periods{
+		WeekStart:       time.Monday,
+		Location:        time.Local,
+		Calendar:        opts.CalendarPeriods,
+		FiscalYearStart: time.January,
+	}
+
+	if opts.WeekStart != "" {
+		found := false
+		for day := time.Sunday; day <= time.Saturday; day++ {
+			if strings.EqualFold(day.String(), opts.WeekStart) {
+				periods.WeekStart = day
+				found = true
+			}
+		}
+		if !found {
+			return Periods{}, fmt.Errorf("Unknown week start: %s", opts.WeekStart)
+		}
+	}
+
+	if opts.TimeZone != "" {
+		location, err := time.LoadLocation(opts.TimeZone)
+		if err != nil {
+			return Periods{}, fmt.Errorf("Unknown time zone %s: %s", opts.TimeZone, err)
+		}
+		periods.Location = location
+	}
+
+	if opts.FiscalYearStart != 0 {
+		if opts.FiscalYearStart < 1 || opts.FiscalYearStart > 12 {
+			return Periods{}, fmt.Errorf("Fiscal year start must be a month between 1 and 12")
+		}
+		periods.FiscalYearStart = time.Month(opts.FiscalYearStart)
+	}
+
+	return periods, nil
+}
+
+// Get the beginnings of the current month and week used for the durations
+func (p Periods) Bounds(now time.Time) (monthStart, weekStart time.Time) {
+	if !p.Calendar {
+		return now.AddDate(0, -1, 0), now.AddDate(0, 0, -7)
+	}
+
+	monthStart, _, _ = p.Range("month", 0, now)
+	weekStart, _, _ = p.Range("week", 0, now)
+	return
+}
+
+// Get the calendar-aligned period of the given kind that is offset periods
+// away from the one containing now; the end is exclusive
+func (p Periods) Range(period string, offset int, now time.Time) (time.Time, time.Time, error) {
+	now = now.In(p.Location)
+	year, month, day := now.Date()
+	today := time.Date(year, month, day, 0, 0, 0, 0, p.Location)
+
+	switch period {
+	case "day":
+		start := today.AddDate(0, 0, offset)
+		return start, start.AddDate(0, 0, 1), nil
+	case "week":
+		shift := (int(today.Weekday()) - int(p.WeekStart) + 7) % 7
+		start := today.AddDate(0, 0, 7*offset-shift)
+		return start, start.AddDate(0, 0, 7), nil
+	case "month":
+		start := time.Date(year, month+time.Month(offset), 1, 0, 0, 0, 0, p.Location)
+		return start, start.AddDate(0, 1, 0), nil
+	case "quarter":
+		shift := (int(month) - int(p.FiscalYearStart) + 12) % 12 % 3
+		start := time.Date(year, month-time.Month(shift)+time.Month(3*offset), 1,
+			0, 0, 0, 0, p.Location)
+		return start, start.AddDate(0, 3, 0), nil
+	case "year":
+		shift := (int(month) - int(p.FiscalYearStart) + 12) % 12
+		start := time.Date(year+offset, month-time.Month(shift), 1, 0, 0, 0, 0, p.Location)
+		return start, start.AddDate(1, 0, 0), nil
+	}
+	return time.Time{}, time.Time{}, fmt.Errorf("Unknown period: %s", period)
+}
+
+// Turn a calendar period into a range of timestamps if one is given
+func (p Periods) resolveRange(from, to uint64, period string, offset int) (uint64, uint64, error) {
+	if period != "" {
+		start, end, err := p.Range(period, offset, time.Now())
+		if err != nil {
+			return 0, 0, err
+		}
+		from, to = uint64(start.Unix()), uint64(end.Unix())
+	}
+
+	if to <= from {
+		return 0, 0, fmt.Errorf("The end of the range must come after its start")
+	}
+	return from, to, nil
+}
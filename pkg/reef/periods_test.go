@@ -0,0 +1,64 @@
+//------------------------------------------------------------------------------
+// Author: Lukasz Janyst <lukasz@jany.st>
+// Date: 17.10.2026
+//
+// Licensed under the GPL 3 License, see the LICENSE file for details.
+//------------------------------------------------------------------------------
+
+package reef
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPeriodRange(t *testing.T) {
+	opts := BackendOpts{WeekStart: "Monday", TimeZone: "UTC", FiscalYearStart: 4}
+	periods, err := NewPeriods(&opts)
+	if err != nil {
+		t.Fatalf("Unable to create the periods: %s", err)
+	}
+
+	// Wednesday
+	now := time.Date(2026, time.February, 11, 15, 30, 0, 0, time.UTC)
+	cases := []struct {
+		period string
+		offset int
+		start  string
+		end    string
+	}{
+		{"day", 0, "2026-02-11", "2026-02-12"},
+		{"week", 0, "2026-02-09", "2026-02-16"},
+		{"week", -1, "2026-02-02", "2026-02-09"},
+		{"month", 0, "2026-02-01", "2026-03-01"},
+		{"month", -2, "2025-12-01", "2026-01-01"},
+		{"quarter", 0, "2026-01-01", "2026-04-01"},
+		{"quarter", 1, "2026-04-01", "2026-07-01"},
+		{"year", 0, "2025-04-01", "2026-04-01"},
+	}
+
+	for _, tc := range cases {
+		start, end, err := periods.Range(tc.period, tc.offset, now)
+		if err != nil {
+			t.Errorf("Unable to compute %s %d: %s", tc.period, tc.offset, err)
+			continue
+		}
+		if start.Format("2006-01-02") != tc.start || end.Format("2006-01-02") != tc.end {
+			t.Errorf("Wrong range for %s %d: %s - %s", tc.period, tc.offset, start, end)
+		}
+	}
+
+	opts.WeekStart = "Sunday"
+	if periods, err = NewPeriods(&opts); err != nil {
+		t.Fatalf("Unable to create the periods: %s", err)
+	}
+	start, _, _ := periods.Range("week", 0, now)
+	if start.Format("2006-01-02") != "2026-02-08" {
+		t.Errorf("Week should start on Sunday, got %s", start)
+	}
+
+	opts.WeekStart = "Caturday"
+	if _, err = NewPeriods(&opts); err == nil {
+		t.Error("Unknown week start should be rejected")
+	}
+}
@@ -0,0 +1,207 @@
+//------------------------------------------------------------------------------
+// Author: Lukasz Janyst <lukasz@jany.st>
+// Date: 17.10.2026
+//
+// Licensed under the GPL 3 License, see the LICENSE file for details.
+//------------------------------------------------------------------------------
+
+package reef
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	log "github.com/sirupsen/logrus"
+)
+
+type postgresDialect struct{}
+
+func (postgresDialect) rebind(query string) string {
+	return numberPlaceholders(query, "$")
+}
+
+func (postgresDialect) groupConcat(expr, separator string) string {
+	return fmt.Sprintf("string_agg(CAST(%s AS TEXT), '%s' ORDER BY %s)", expr, separator, expr)
+}
+
+func (postgresDialect) dateBucket(groupBy, column string, zoneOffset int,
+	weekStart time.Weekday) string {
+	local := fmt.Sprintf("(to_timestamp(%s + %d) AT TIME ZONE 'UTC')", column, zoneOffset)
+	switch groupBy {
+	case GroupByWeek:
+		return fmt.Sprintf("to_char(CAST(%s AS DATE) - "+
+			"CAST(EXTRACT(DOW FROM %s) + %d AS INTEGER) %% 7, 'YYYY-MM-DD')",
+			local, local, 7-int(weekStart))
+	case GroupByMonth:
+		return "to_char(" + local + ", 'YYYY-MM-01')"
+	}
+	return "to_char(" + local + ", 'YYYY-MM-DD')"
+}
+
+func (postgresDialect) insert(tx *dbConn, query string, args ...interface{}) (uint64, error) {
+	query = strings.TrimSuffix(strings.TrimSpace(query), ";") + " RETURNING id;"
+	var id uint64
+	err := tx.QueryRow(query, args...).Scan(&id)
+	return id, err
+}
+
+func (postgresDialect) isUniqueViolation(err error) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && pqErr.Code == "23505"
+}
+
+func (postgresDialect) snapshotOptions() *sql.TxOptions {
+	return &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}
+}
+
+// The Postgres schema is versioned separately from the SQLite one. The names
+// and titles use the C collation to sort the way SQLite does and, like in
+// SQLite, the foreign keys are not enforced.
+var postgresMigrations = &migrationRegistry{
+	migrations: []Migration{
+		{
+			Version: 1,
+			Name:    "Create the schema",
+			Up: []CommandEntry{
+				{
+					"CREATE TABLE tags (" +
+						"id BIGSERIAL PRIMARY KEY, " +
+						`name TEXT COLLATE "C" UNIQUE NOT NULL, ` +
+						"color TEXT NOT NULL, " +
+						"parentId BIGINT NOT NULL DEFAULT 0);",
+					"Unable to create the tags table",
+				},
+				{
+					"CREATE TABLE projects (" +
+						"id BIGSERIAL PRIMARY KEY, " +
+						`title TEXT COLLATE "C" UNIQUE NOT NULL, ` +
+						"description TEXT NOT NULL, " +
+						"parentId BIGINT NOT NULL DEFAULT 0, " +
+						"status TEXT NOT NULL DEFAULT 'active');",
+					"Unable to create the projects table",
+				},
+				{
+					"CREATE TABLE projectStatusHistory (" +
+						"id BIGSERIAL PRIMARY KEY, " +
+						"projectId BIGINT NOT NULL, " +
+						"status TEXT NOT NULL, " +
+						"timestamp BIGINT NOT NULL);",
+					"Unable to create the project status history table",
+				},
+				{
+					"CREATE TABLE goals (" +
+						"id BIGSERIAL PRIMARY KEY, " +
+						"projectId BIGINT NOT NULL DEFAULT 0, " +
+						"tagId BIGINT NOT NULL DEFAULT 0, " +
+						"period TEXT NOT NULL, " +
+						"minimum BIGINT NOT NULL DEFAULT 0, " +
+						"maximum BIGINT NOT NULL DEFAULT 0);",
+					"Unable to create the goals table",
+				},
+				{
+					"CREATE TABLE projectTags (" +
+						"projectId BIGINT NOT NULL, " +
+						"tagId BIGINT NOT NULL, " +
+						"PRIMARY KEY (projectId, tagId));",
+					"Unable to create the project-tag table",
+				},
+				{
+					"CREATE TABLE tasks (" +
+						"id BIGSERIAL PRIMARY KEY, " +
+						"projectId BIGINT NOT NULL, " +
+						"done BOOLEAN NOT NULL, " +
+						"priority BIGINT NOT NULL, " +
+						`title TEXT COLLATE "C" NOT NULL, ` +
+						"description TEXT NOT NULL, " +
+						"startDate BIGINT NOT NULL DEFAULT 0, " +
+						"dueDate BIGINT NOT NULL DEFAULT 0, " +
+						"parentId BIGINT NOT NULL DEFAULT 0, " +
+						"recurrence TEXT NOT NULL DEFAULT '', " +
+						"recurrenceInterval BIGINT NOT NULL DEFAULT 1, " +
+						"estimate BIGINT NOT NULL DEFAULT 0, " +
+						"position BIGINT NOT NULL DEFAULT 0, " +
+						"milestoneId BIGINT NOT NULL DEFAULT 0);",
+					"Unable to create the tasks table",
+				},
+				{
+					"CREATE TABLE sessions (" +
+						"id BIGSERIAL PRIMARY KEY, " +
+						"projectId BIGINT NOT NULL, " +
+						"timestamp BIGINT NOT NULL, " +
+						"duration BIGINT NOT NULL, " +
+						"taskId BIGINT NOT NULL DEFAULT 0, " +
+						"note TEXT NOT NULL DEFAULT '');",
+					"Unable to create the sessions table",
+				},
+				{
+					"CREATE TABLE timers (" +
+						"projectId BIGINT PRIMARY KEY, " +
+						"started BIGINT NOT NULL, " +
+						"resumed BIGINT NOT NULL, " +
+						"elapsed BIGINT NOT NULL, " +
+						"running BOOLEAN NOT NULL);",
+					"Unable to create the timers table",
+				},
+				{
+					"CREATE TABLE taskDependencies (" +
+						"taskId BIGINT NOT NULL, " +
+						"blockerId BIGINT NOT NULL, " +
+						"PRIMARY KEY (taskId, blockerId));",
+					"Unable to create the task dependency table",
+				},
+				{
+					"CREATE TABLE taskTags (" +
+						"taskId BIGINT NOT NULL, " +
+						"tagId BIGINT NOT NULL, " +
+						"PRIMARY KEY (taskId, tagId));",
+					"Unable to create the task-tag table",
+				},
+				{
+					"CREATE TABLE milestones (" +
+						"id BIGSERIAL PRIMARY KEY, " +
+						"projectId BIGINT NOT NULL, " +
+						`name TEXT COLLATE "C" NOT NULL, ` +
+						"targetDate BIGINT NOT NULL);",
+					"Unable to create the milestones table",
+				},
+			},
+			Down: []CommandEntry{
+				{
+					"DROP TABLE tags, projects, projectStatusHistory, goals, projectTags, " +
+						"tasks, sessions, timers, taskDependencies, taskTags, milestones;",
+					"Unable to drop the tables",
+				},
+			},
+		},
+	},
+}
+
+func (db *Database) initializePostgres(dsn string) error {
+	if err := db.open("postgres", dsn, postgresDialect{}); err != nil {
+		return err
+	}
+	if err := db.db.Ping(); err != nil {
+		return fmt.Errorf("Unable to connect to the database: %s", err)
+	}
+	log.Info("Using PostgreSQL for database storage")
+	db.migrations = postgresMigrations
+
+	queries := []CommandEntry{
+		{
+			"CREATE TABLE IF NOT EXISTS metadata (key TEXT PRIMARY KEY, value TEXT NOT NULL);",
+			"Unable to create the metadata table",
+		},
+		{
+			"CREATE TABLE IF NOT EXISTS migrationHistory (" +
+				"id BIGSERIAL PRIMARY KEY, " +
+				"version BIGINT NOT NULL, " +
+				"direction TEXT NOT NULL, " +
+				"timestamp BIGINT NOT NULL);",
+			"Unable to create the migration history table",
+		},
+	}
+	return executeQueries(db.db, queries)
+}
@@ -0,0 +1,42 @@
+//------------------------------------------------------------------------------
+// Author: Lukasz Janyst <lukasz@jany.st>
+// Date: 17.10.2026
+//
+// Licensed under the GPL 3 License, see the LICENSE file for details.
+//------------------------------------------------------------------------------
+
+package reef
+
+import (
+	"fmt"
+	"time"
+)
+
+// Recurrence rules understood by the task scheduler; a rule is combined with
+// an interval, so that "weekly" with interval 2 means every other week
+var recurrenceRules = map[string][3]int{
+	"":        {0, 0, 0},
+	"daily":   {0, 0, 1},
+	"weekly":  {0, 0, 7},
+	"monthly": {0, 1, 0},
+	"yearly":  {1, 0, 0},
+}
+
+func checkRecurrence(rule string, interval uint64) error {
+	if _, ok := recurrenceRules[rule]; !ok {
+		return fmt.Errorf("Unknown recurrence rule: %s", rule)
+	}
+	if rule != "" && interval == 0 {
+		return fmt.Errorf("The recurrence interval must be greater than zero")
+	}
+	return nil
+}
+
+// Compute the date of the count-th next occurrence of a recurring event; it
+// is computed from the original date, so that the monthly occurrences don't
+// drift when they hit shorter months
+func nextOccurrence(date time.Time, rule string, interval, count uint64) time.Time {
+	step := recurrenceRules[rule]
+	n := int(interval * count)
+	return date.AddDate(step[0]*n, step[1]*n, step[2]*n)
+}
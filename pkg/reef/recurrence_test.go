@@ -0,0 +1,99 @@
+//------------------------------------------------------------------------------
+// Author: Lukasz Janyst <lukasz@jany.st>
+// Date: 17.10.2026
+//
+// Licensed under the GPL 3 License, see the LICENSE file for details.
+//------------------------------------------------------------------------------
+
+package reef
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRecurrence(t *testing.T) {
+	db, cleanup := newTempDatabase(t)
+	defer cleanup()
+
+	memory, err := NewMemoryStore(&BackendOpts{})
+	if err != nil {
+		t.Fatalf("Unable to create the memory store: %s", err)
+	}
+
+	now := time.Now().Unix()
+	overdue := time.Unix(now-17*86400, 0)
+	stores := map[string]Store{"database": db, "memory": memory}
+	for name, store := range stores {
+		steps := []func() error{
+			func() error { _, err := store.CreateProject("Alpha", "", []uint64{}, 0); return err },
+			func() error {
+				return store.AddTask(1, 0, TaskDetails{Title: "Report", Recurrence: "weekly",
+					RecurrenceInterval: 1, DueDate: uint64(overdue.Unix())})
+			},
+			func() error { _, err := store.ToggleTask(1, false); return err },
+			func() error { return store.AddTask(1, 0, TaskDetails{Title: "Release"}) },
+			func() error {
+				return store.AddTask(1, 3, TaskDetails{Title: "Backup", Recurrence: "daily",
+					RecurrenceInterval: 1, DueDate: uint64(now + 3600)})
+			},
+			func() error { _, err := store.ToggleTask(3, true); return err },
+			func() error { _, err := store.CreateTag("needs-review", "#ff0000", 0); return err },
+			func() error { return store.CreateMilestone(1, "M1", uint64(now+30*86400)) },
+			func() error {
+				return store.AddTask(1, 0, TaskDetails{Title: "Review", Recurrence: "weekly",
+					RecurrenceInterval: 1, DueDate: uint64(now + 3600)})
+			},
+			func() error { return store.AddTask(1, 5, TaskDetails{Title: "Checklist"}) },
+			func() error { _, err := store.AddTaskTag(5, 1); return err },
+			func() error { _, err := store.AddTaskTag(6, 1); return err },
+			func() error { _, err := store.AssignMilestone(5, 1); return err },
+			func() error { _, err := store.ToggleTask(5, true); return err },
+		}
+		for i, step := range steps {
+			if err := step(); err != nil {
+				t.Fatalf("%s: unable to populate the store, step %d: %s", name, i, err)
+			}
+		}
+
+		project, err := store.GetProjectById(1)
+		if err != nil {
+			t.Fatalf("%s: unable to get the project: %s", name, err)
+		}
+		tasks := make(map[uint64]Task)
+		for _, task := range project.Tasks {
+			tasks[task.Id] = task
+		}
+
+		// An overdue task is scheduled for its first occurrence in the future
+		next, ok := tasks[2]
+		expected := uint64(overdue.AddDate(0, 0, 21).Unix())
+		if !ok || next.Title != "Report" || next.DueDate != expected || next.Done {
+			t.Errorf("%s: wrong next occurrence: %+v, expected due date %d", name, next,
+				expected)
+		}
+
+		// The subtasks finished with their parent are not scheduled again
+		if len(tasks) != 8 {
+			t.Errorf("%s: wrong number of tasks: %d", name, len(tasks))
+		}
+		if backup := tasks[4]; !backup.Done || backup.Recurrence != "daily" {
+			t.Errorf("%s: wrong finished subtask: %+v", name, backup)
+		}
+
+		// The next occurrence keeps the tags, the milestone and the subtasks
+		review, checklist := tasks[7], tasks[8]
+		if review.Title != "Review" || review.Done || review.MilestoneId != 1 ||
+			!reflect.DeepEqual(review.Tags, []uint64{1}) || review.Recurrence != "weekly" {
+			t.Errorf("%s: wrong next occurrence: %+v", name, review)
+		}
+		if checklist.Title != "Checklist" || checklist.ParentId != 7 || checklist.Done ||
+			!reflect.DeepEqual(checklist.Tags, []uint64{1}) {
+			t.Errorf("%s: wrong subtask of the next occurrence: %+v", name, checklist)
+		}
+		if tasks[5].Recurrence != "" {
+			t.Errorf("%s: the finished occurrence is still recurring: %+v", name, tasks[5])
+		}
+	}
+}
@@ -0,0 +1,241 @@
+//------------------------------------------------------------------------------
+// Author: Lukasz Janyst <lukasz@jany.st>
+// Date: 17.10.2026
+//
+// Licensed under the GPL 3 License, see the LICENSE file for details.
+//------------------------------------------------------------------------------
+
+package reef
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	GroupByDay     = "day"
+	GroupByWeek    = "week"
+	GroupByMonth   = "month"
+	GroupByProject = "project"
+	GroupByTag     = "tag"
+)
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// Build the common part of the report queries: the filter subtrees and the
+// conditions selecting the sessions; the project and tag filters include
+// the descendants of the given projects and tags
+func buildReportFilter(from, to uint64, projectIds, tagIds []uint64) (string, string, []interface{}) {
+	ctes := []string{}
+	conditions := []string{"sessions.timestamp >= ?", "sessions.timestamp < ?"}
+	args := []interface{}{}
+
+	if len(projectIds) != 0 {
+		ctes = append(ctes, "projectFilter(id) AS ("+
+			"SELECT id FROM projects WHERE id IN ("+placeholders(len(projectIds))+") "+
+			"UNION SELECT projects.id FROM projects "+
+			"JOIN projectFilter ON projects.parentId = projectFilter.id)")
+		conditions = append(conditions, "sessions.projectId IN (SELECT id FROM projectFilter)")
+		for _, id := range projectIds {
+			args = append(args, id)
+		}
+	}
+
+	if len(tagIds) != 0 {
+		ctes = append(ctes, "tagFilter(id) AS ("+
+			"SELECT id FROM tags WHERE id IN ("+placeholders(len(tagIds))+") "+
+			"UNION SELECT tags.id FROM tags JOIN tagFilter ON tags.parentId = tagFilter.id)")
+		conditions = append(conditions, "sessions.projectId IN (SELECT projectId FROM projectTags "+
+			"WHERE tagId IN (SELECT id FROM tagFilter))")
+		for _, id := range tagIds {
+			args = append(args, id)
+		}
+	}
+
+	with := ""
+	if len(ctes) != 0 {
+		with = "WITH RECURSIVE " + strings.Join(ctes, ", ") + " "
+	}
+	args = append(args, from, to)
+	return with, "WHERE " + strings.Join(conditions, " AND ") + " ", args
+}
+
+// A part of a time range over which the time zone keeps the same offset
+type zoneSegment struct {
+	from   uint64
+	to     uint64
+	offset int
+}
+
+// Split the [from, to) range where the offset of the time zone changes; the
+// offsets change at most once a day, so each day is checked and the exact
+// second of the change is looked up with a binary search
+func zoneSegments(loc *time.Location, from, to uint64) []zoneSegment {
+	offsetAt := func(t uint64) int {
+		_, offset := time.Unix(int64(t), 0).In(loc).Zone()
+		return offset
+	}
+
+	segments := []zoneSegment{}
+	start, offset := from, offsetAt(from)
+	for t := from; t+1 < to; {
+		next := t + 86400
+		if next > to-1 {
+			next = to - 1
+		}
+		if offsetAt(next) == offset {
+			t = next
+			continue
+		}
+		lo, hi := t, next
+		for hi-lo > 1 {
+			mid := lo + (hi-lo)/2
+			if offsetAt(mid) == offset {
+				lo = mid
+			} else {
+				hi = mid
+			}
+		}
+		segments = append(segments, zoneSegment{start, hi, offset})
+		start, offset, t = hi, offsetAt(hi), hi
+	}
+	return append(segments, zoneSegment{start, to, offset})
+}
+
+// Aggregate the time logged in the [from, to) range, which can also be given
+// as a calendar period; the day, week and month buckets are computed in the
+// configured time zone, separately for each of its offsets in the range. The
+// total and the groups are read in one transaction, so that they agree.
+func (db *Database) QueryReport(from, to uint64, period string, offset int,
+	projectIds, tagIds []uint64, groupBy string) (report Report, err error) {
+	from, to, err = db.periods.resolveRange(from, to, period, offset)
+	if err != nil {
+		return Report{}, err
+	}
+
+	err = db.readTransaction(func(tx *Database) error {
+		report, err = tx.queryReport(from, to, projectIds, tagIds, groupBy)
+		return err
+	})
+	return
+}
+
+func (db *Database) queryReport(from, to uint64, projectIds, tagIds []uint64,
+	groupBy string) (Report, error) {
+	with, where, args := buildReportFilter(from, to, projectIds, tagIds)
+	report := Report{From: from, To: to, GroupBy: groupBy, Groups: []ReportGroup{}}
+
+	var first, last sql.NullInt64
+	query := with + "SELECT COALESCE(SUM(sessions.duration), 0), COUNT(*), " +
+		"MIN(sessions.timestamp), MAX(sessions.timestamp) FROM sessions " +
+		"JOIN projects ON projects.id = sessions.projectId " + where
+	if err := db.db.QueryRow(query, args...).Scan(&report.DurationTotal,
+		&report.NumberOfSessions, &first, &last); err != nil {
+		return Report{}, fmt.Errorf("Unable to compute the report total: %s", err)
+	}
+
+	timeBuckets := false
+	switch groupBy {
+	case GroupByDay, GroupByWeek, GroupByMonth:
+		timeBuckets = true
+	case GroupByProject, GroupByTag:
+	default:
+		return Report{}, fmt.Errorf("Unknown report grouping: %s", groupBy)
+	}
+	if report.NumberOfSessions == 0 {
+		return report, nil
+	}
+
+	segments := []zoneSegment{{from, to, 0}}
+	if timeBuckets {
+		segments = zoneSegments(db.periods.Location, uint64(first.Int64),
+			uint64(last.Int64)+1)
+	}
+
+	groups := make(map[ReportGroup]int)
+	for _, segment := range segments {
+		var key string
+		switch groupBy {
+		case GroupByDay, GroupByWeek, GroupByMonth:
+			key = "0, " + db.db.dialect.dateBucket(groupBy, "sessions.timestamp",
+				segment.offset, db.periods.WeekStart)
+		case GroupByProject:
+			key = "sessions.projectId, projects.title"
+		case GroupByTag:
+			key = "COALESCE(tags.id, 0), COALESCE(tags.name, '')"
+		}
+
+		query = with + "SELECT " + key + ", SUM(sessions.duration), COUNT(*) FROM sessions " +
+			"JOIN projects ON projects.id = sessions.projectId "
+		if groupBy == GroupByTag {
+			// A session counts towards every tag of its project, or only the ones
+			// selected by the filter
+			query += "LEFT JOIN projectTags ON projectTags.projectId = sessions.projectId "
+			if len(tagIds) != 0 {
+				query += "AND projectTags.tagId IN (SELECT id FROM tagFilter) "
+			}
+			query += "LEFT JOIN tags ON tags.id = projectTags.tagId "
+		}
+		query += where + "AND sessions.timestamp >= ? AND sessions.timestamp < ? " +
+			"GROUP BY 1, 2;"
+
+		segmentArgs := append(append([]interface{}{}, args...), segment.from, segment.to)
+		err := db.addReportGroups(&report, groups, query, segmentArgs)
+		if err != nil {
+			return Report{}, err
+		}
+	}
+
+	sort.SliceStable(report.Groups, func(i, j int) bool {
+		return report.Groups[i].Label < report.Groups[j].Label
+	})
+
+	if timeBuckets {
+		for i := range report.Groups {
+			start, err := time.ParseInLocation("2006-01-02", report.Groups[i].Label,
+				db.periods.Location)
+			if err != nil {
+				return Report{}, fmt.Errorf("Unable to parse the report date: %s", err)
+			}
+			report.Groups[i].Start = uint64(start.Unix())
+		}
+	}
+	return report, nil
+}
+
+// Add the groups returned by the query to the report, merging them with the
+// groups of the same key computed for other parts of the range
+func (db *Database) addReportGroups(report *Report, groups map[ReportGroup]int, query string,
+	args []interface{}) error {
+	rows, err := db.db.Query(query, args...)
+	if err != nil {
+		return fmt.Errorf("Unable to query the report: %s", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key ReportGroup
+		var duration uint64
+		var sessions uint64
+		if err := rows.Scan(&key.Id, &key.Label, &duration, &sessions); err != nil {
+			return fmt.Errorf("Unable to scan the report: %s", err)
+		}
+		idx, ok := groups[key]
+		if !ok {
+			idx = len(report.Groups)
+			groups[key] = idx
+			report.Groups = append(report.Groups, key)
+		}
+		report.Groups[idx].Duration += duration
+		report.Groups[idx].NumberOfSessions += sessions
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("Unable to process the report: %s", err)
+	}
+	return nil
+}
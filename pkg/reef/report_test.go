@@ -0,0 +1,116 @@
+//------------------------------------------------------------------------------
+// Author: Lukasz Janyst <lukasz@jany.st>
+// Date: 17.10.2026
+//
+// Licensed under the GPL 3 License, see the LICENSE file for details.
+//------------------------------------------------------------------------------
+
+package reef
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// Local time in Warsaw; the clocks go back an hour on 25.10.2026
+func warsawTime(t *testing.T, month time.Month, day, hour, minute int) uint64 {
+	location, err := time.LoadLocation("Europe/Warsaw")
+	if err != nil {
+		t.Skipf("The time zone database is not available: %s", err)
+	}
+	return uint64(time.Date(2026, month, day, hour, minute, 0, 0, location).Unix())
+}
+
+func checkReportGroups(t *testing.T, name string, store Store, from, to uint64,
+	tagIds []uint64, groupBy string, expected []string) {
+	report, err := store.QueryReport(from, to, "", 0, nil, tagIds, groupBy)
+	if err != nil {
+		t.Fatalf("%s: unable to query the report by %s: %s", name, groupBy, err)
+	}
+	labels := []string{}
+	for _, group := range report.Groups {
+		labels = append(labels, group.Label)
+	}
+	if !reflect.DeepEqual(labels, expected) {
+		t.Errorf("%s: wrong groups by %s: %v, expected %v", name, groupBy, labels, expected)
+	}
+}
+
+func TestReportAcrossTimeChange(t *testing.T) {
+	opts := &BackendOpts{TimeZone: "Europe/Warsaw"}
+	from := warsawTime(t, time.October, 19, 0, 0)
+	to := warsawTime(t, time.November, 2, 0, 0)
+
+	db, cleanup := newTempDatabase(t)
+	defer cleanup()
+	periods, err := NewPeriods(opts)
+	if err != nil {
+		t.Fatalf("Unable to create the periods: %s", err)
+	}
+	db.periods = periods
+
+	memory, err := NewMemoryStore(opts)
+	if err != nil {
+		t.Fatalf("Unable to create the memory store: %s", err)
+	}
+
+	stores := map[string]Store{"database": db, "memory": memory}
+	for name, store := range stores {
+		steps := []func() error{
+			func() error { _, err := store.CreateTag("work", "#ff0000", 0); return err },
+			func() error { _, err := store.CreateTag("ops", "#00ff00", 0); return err },
+			func() error { _, err := store.CreateProject("Alpha", "", []uint64{1, 2}, 0); return err },
+			// Late evening sessions before and after the clocks go back
+			func() error { return store.AddSession(1, 0, 30, warsawTime(t, 10, 20, 23, 30), "") },
+			func() error { return store.AddSession(1, 0, 30, warsawTime(t, 10, 25, 23, 30), "") },
+			func() error { return store.AddSession(1, 0, 30, warsawTime(t, 10, 31, 23, 30), "") },
+		}
+		for i, step := range steps {
+			if err := step(); err != nil {
+				t.Fatalf("%s: unable to populate the store, step %d: %s", name, i, err)
+			}
+		}
+
+		checkReportGroups(t, name, store, from, to, nil, GroupByDay,
+			[]string{"2026-10-20", "2026-10-25", "2026-10-31"})
+		checkReportGroups(t, name, store, from, to, nil, GroupByWeek,
+			[]string{"2026-10-19", "2026-10-26"})
+		checkReportGroups(t, name, store, from, to, nil, GroupByMonth,
+			[]string{"2026-10-01"})
+		checkReportGroups(t, name, store, from, to, nil, GroupByTag,
+			[]string{"ops", "work"})
+		checkReportGroups(t, name, store, from, to, []uint64{1}, GroupByTag,
+			[]string{"work"})
+	}
+}
+
+// The sessions of the projects deleted by older versions count neither
+// towards the groups nor towards the total
+func TestReportTotal(t *testing.T) {
+	db, cleanup := newTempDatabase(t)
+	defer cleanup()
+
+	now := uint64(time.Now().Unix())
+	steps := []func() error{
+		func() error { _, err := db.CreateProject("Alpha", "", []uint64{}, 0); return err },
+		func() error { _, err := db.CreateProject("Beta", "", []uint64{}, 0); return err },
+		func() error { return db.AddSession(1, 0, 30, now-3600, "") },
+		func() error { return db.AddSession(2, 0, 45, now-3600, "") },
+		func() error { _, err := db.db.Exec("DELETE FROM projects WHERE id = 1;"); return err },
+	}
+	for i, step := range steps {
+		if err := step(); err != nil {
+			t.Fatalf("Unable to populate the database, step %d: %s", i, err)
+		}
+	}
+
+	report, err := db.QueryReport(now-86400, now, "", 0, nil, nil, GroupByProject)
+	if err != nil {
+		t.Fatalf("Unable to query the report: %s", err)
+	}
+	if report.DurationTotal != 45 || report.NumberOfSessions != 1 || len(report.Groups) != 1 ||
+		report.Groups[0].Duration != 45 {
+		t.Errorf("Wrong report: %+v", report)
+	}
+}
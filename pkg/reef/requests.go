@@ -8,27 +8,56 @@
 package reef
 
 type Request struct {
-	Id                  string            `json:"id"`
-	Type                string            `json:"type"`
-	Action              string            `json:"action"`
-	TagNewParams        TagNewParams      `json:"tagNewParams"`
-	TagDeleteParams     uint64            `json:"tagDeleteParams"`
-	TagEditParams       TagEditParams     `json:"tagEditParams"`
-	ProjectNewParams    ProjectNewParams  `json:"projectNewParams"`
-	ProjectGetParams    uint64            `json:"projectGetParams"`
-	ProjectDeleteParams uint64            `json:"projectDeleteParams"`
-	ProjectEditParams   ProjectEditParams `json:"projectEditParams"`
-	TaskNewParams       TaskNewParams     `json:"taskNewParams"`
-	TaskDeleteParams    uint64            `json:"taskDeleteParams"`
-	TaskToggleParams    uint64            `json:"taskToggleParams"`
-	TaskEditParams      TaskEditParams    `json:"taskEditParams"`
-	SessionNewParams    SessionNewParams  `json:"sessionNewParams"`
-	SessionDeleteParams uint64            `json:"sessionDeleteParams"`
+	Id                    string                `json:"id"`
+	Type                  string                `json:"type"`
+	Action                string                `json:"action"`
+	TagNewParams          TagNewParams          `json:"tagNewParams"`
+	TagDeleteParams       uint64                `json:"tagDeleteParams"`
+	TagEditParams         TagEditParams         `json:"tagEditParams"`
+	TagMoveParams         TagMoveParams         `json:"tagMoveParams"`
+	ProjectNewParams      ProjectNewParams      `json:"projectNewParams"`
+	ProjectGetParams      uint64                `json:"projectGetParams"`
+	ProjectDeleteParams   uint64                `json:"projectDeleteParams"`
+	ProjectEditParams     ProjectEditParams     `json:"projectEditParams"`
+	ProjectMoveParams     ProjectMoveParams     `json:"projectMoveParams"`
+	ProjectStatusParams   ProjectStatusParams   `json:"projectStatusParams"`
+	TaskNewParams         TaskNewParams         `json:"taskNewParams"`
+	TaskDeleteParams      uint64                `json:"taskDeleteParams"`
+	TaskToggleParams      TaskToggleParams      `json:"taskToggleParams"`
+	TaskEditParams        TaskEditParams        `json:"taskEditParams"`
+	SessionNewParams      SessionNewParams      `json:"sessionNewParams"`
+	SessionDeleteParams   uint64                `json:"sessionDeleteParams"`
+	SessionEditParams     SessionEditParams     `json:"sessionEditParams"`
+	SessionStartParams    uint64                `json:"sessionStartParams"`
+	SessionStopParams     uint64                `json:"sessionStopParams"`
+	SessionPauseParams    uint64                `json:"sessionPauseParams"`
+	TaskListDueParams     uint64                `json:"taskListDueParams"`
+	TaskLinkParams        TaskLinkParams        `json:"taskLinkParams"`
+	TaskUnlinkParams      TaskLinkParams        `json:"taskUnlinkParams"`
+	TaskReorderParams     TaskReorderParams     `json:"taskReorderParams"`
+	TaskTagAddParams      TaskTagParams         `json:"taskTagAddParams"`
+	TaskTagRemoveParams   TaskTagParams         `json:"taskTagRemoveParams"`
+	ReportEstimatesParams uint64                `json:"reportEstimatesParams"`
+	ReportRangeParams     ReportRangeParams     `json:"reportRangeParams"`
+	ReportQueryParams     ReportQueryParams     `json:"reportQueryParams"`
+	MilestoneNewParams    MilestoneNewParams    `json:"milestoneNewParams"`
+	MilestoneEditParams   MilestoneEditParams   `json:"milestoneEditParams"`
+	MilestoneDeleteParams uint64                `json:"milestoneDeleteParams"`
+	MilestoneAssignParams MilestoneAssignParams `json:"milestoneAssignParams"`
+	GoalNewParams         GoalNewParams         `json:"goalNewParams"`
+	GoalEditParams        GoalEditParams        `json:"goalEditParams"`
+	GoalDeleteParams      uint64                `json:"goalDeleteParams"`
 }
 
 type TagNewParams struct {
-	Name  string `json:"name"`
-	Color string `json:"color"`
+	Name     string `json:"name"`
+	Color    string `json:"color"`
+	ParentId uint64 `json:"parentId"`
+}
+
+type TagMoveParams struct {
+	Id       uint64 `json:"id"`
+	ParentId uint64 `json:"parentId"`
 }
 
 type TagEditParams struct {
@@ -41,6 +70,12 @@ type ProjectNewParams struct {
 	Name        string   `json:"name"`
 	Tags        []uint64 `json:"tags"`
 	Description string   `json:"description"`
+	ParentId    uint64   `json:"parentId"`
+}
+
+type ProjectMoveParams struct {
+	Id       uint64 `json:"id"`
+	ParentId uint64 `json:"parentId"`
 }
 
 type ProjectEditParams struct {
@@ -50,22 +85,117 @@ type ProjectEditParams struct {
 	Tags        []uint64 `json:"tags"`
 }
 
+type ProjectStatusParams struct {
+	Id     uint64 `json:"id"`
+	Status string `json:"status"`
+}
+
+// Task attributes that can be set both when creating and when editing a task
+type TaskDetails struct {
+	Title              string `json:"title"`
+	Description        string `json:"description"`
+	Priority           uint64 `json:"priority"`
+	StartDate          uint64 `json:"startDate"`
+	DueDate            uint64 `json:"dueDate"`
+	Recurrence         string `json:"recurrence"`
+	RecurrenceInterval uint64 `json:"recurrenceInterval"`
+	Estimate           uint64 `json:"estimate"`
+}
+
 type TaskNewParams struct {
-	ProjectId   uint64 `json:"projectId"`
-	Title       string `json:"title"`
-	Description string `json:"description"`
-	Priority    uint64 `json:"priority"`
+	ProjectId uint64 `json:"projectId"`
+	ParentId  uint64 `json:"parentId"`
+	TaskDetails
+}
+
+type TaskToggleParams struct {
+	TaskId  uint64 `json:"taskId"`
+	Cascade bool   `json:"cascade"`
+}
+
+type TaskLinkParams struct {
+	TaskId    uint64 `json:"taskId"`
+	BlockerId uint64 `json:"blockerId"`
+}
+
+type TaskTagParams struct {
+	TaskId uint64 `json:"taskId"`
+	TagId  uint64 `json:"tagId"`
+}
+
+type TaskReorderParams struct {
+	TaskId   uint64 `json:"taskId"`
+	TargetId uint64 `json:"targetId"`
+	After    bool   `json:"after"`
 }
 
 type TaskEditParams struct {
+	TaskId uint64 `json:"taskId"`
+	TaskDetails
+}
+
+type MilestoneNewParams struct {
+	ProjectId  uint64 `json:"projectId"`
+	Name       string `json:"name"`
+	TargetDate uint64 `json:"targetDate"`
+}
+
+type MilestoneEditParams struct {
+	Id         uint64 `json:"id"`
+	Name       string `json:"name"`
+	TargetDate uint64 `json:"targetDate"`
+}
+
+type MilestoneAssignParams struct {
 	TaskId      uint64 `json:"taskId"`
-	Title       string `json:"title"`
-	Description string `json:"description"`
-	Priority    uint64 `json:"priority"`
+	MilestoneId uint64 `json:"milestoneId"`
+}
+
+// Either an explicit [from, to) range of unix timestamps, or a calendar period
+// (day, week, month, quarter, year) offset from the current one
+type ReportRangeParams struct {
+	From   uint64 `json:"from"`
+	To     uint64 `json:"to"`
+	Period string `json:"period"`
+	Offset int    `json:"offset"`
+}
+
+// The range is given the same way as for ReportRangeParams; the grouping is
+// one of day, week, month, project and tag
+type ReportQueryParams struct {
+	ReportRangeParams
+	ProjectIds []uint64 `json:"projectIds"`
+	TagIds     []uint64 `json:"tagIds"`
+	GroupBy    string   `json:"groupBy"`
+}
+
+type GoalNewParams struct {
+	ProjectId uint64 `json:"projectId"`
+	TagId     uint64 `json:"tagId"`
+	Period    string `json:"period"`
+	Minimum   uint64 `json:"minimum"`
+	Maximum   uint64 `json:"maximum"`
+}
+
+type GoalEditParams struct {
+	Id      uint64 `json:"id"`
+	Period  string `json:"period"`
+	Minimum uint64 `json:"minimum"`
+	Maximum uint64 `json:"maximum"`
 }
 
 type SessionNewParams struct {
 	ProjectId uint64 `json:"projectId"`
+	TaskId    uint64 `json:"taskId"`
 	Duration  uint64 `json:"duration"`
 	Date      uint64 `json:"date"`
+	Note      string `json:"note"`
+}
+
+type SessionEditParams struct {
+	Id       uint64 `json:"id"`
+	TaskId   uint64 `json:"taskId"`
+	Duration uint64 `json:"duration"`
+	Date     uint64 `json:"date"`
+	Note     string `json:"note"`
 }
@@ -7,49 +7,185 @@
 
 package reef
 
+import "time"
+
 type Tag struct {
 	Id               uint64 `json:"id"`
 	Name             string `json:"name"`
 	Color            string `json:"color"`
+	ParentId         uint64 `json:"parentId"`
 	DurationTotal    uint64 `json:"durationTotal"`
 	DurationMonth    uint64 `json:"durationMonth"`
 	DurationWeek     uint64 `json:"durationWeek"`
 	NumberOfProjects uint32 `json:"numProjects"`
+	NumberOfTasks    uint32 `json:"numTasks"`
+	Goals            []Goal `json:"goals"`
+}
+
+// A time budget; the limits and the time spent in the current period are in
+// minutes, zero meaning no limit
+type Goal struct {
+	Id         uint64  `json:"id"`
+	ProjectId  uint64  `json:"projectId"`
+	TagId      uint64  `json:"tagId"`
+	Period     string  `json:"period"`
+	Minimum    uint64  `json:"minimum"`
+	Maximum    uint64  `json:"maximum"`
+	Spent      uint64  `json:"spent"`
+	Progress   float32 `json:"progress"`
+	Met        bool    `json:"met"`
+	OverBudget bool    `json:"overBudget"`
+}
+
+type Durations struct {
+	DurationTotal uint64 `json:"durationTotal"`
+	DurationMonth uint64 `json:"durationMonth"`
+	DurationWeek  uint64 `json:"durationWeek"`
 }
 
 type Summary struct {
 	Id           uint64   `json:"id"`
 	Title        string   `json:"title"`
+	ParentId     uint64   `json:"parentId"`
+	Status       string   `json:"status"`
 	Tags         []uint64 `json:"tags"`
 	Completeness float32  `json:"completeness"`
 }
 
+type SummaryNode struct {
+	Summary
+	Children []SummaryNode `json:"children"`
+}
+
 type Task struct {
-	Id          uint64 `json:"id"`
-	ProjectId   uint64 `json:"projectId"`
-	Title       string `json:"title"`
-	Description string `json:"description"`
-	Priority    uint8  `json:"priority"`
-	Done        bool   `json:"done"`
+	Id                 uint64   `json:"id"`
+	ProjectId          uint64   `json:"projectId"`
+	ParentId           uint64   `json:"parentId"`
+	Title              string   `json:"title"`
+	Description        string   `json:"description"`
+	Priority           uint8    `json:"priority"`
+	Done               bool     `json:"done"`
+	StartDate          uint64   `json:"startDate"`
+	DueDate            uint64   `json:"dueDate"`
+	Recurrence         string   `json:"recurrence"`
+	RecurrenceInterval uint64   `json:"recurrenceInterval"`
+	Estimate           uint64   `json:"estimate"`
+	Position           uint64   `json:"position"`
+	MilestoneId        uint64   `json:"milestoneId"`
+	Tags               []uint64 `json:"tags"`
+	BlockedBy          []uint64 `json:"blockedBy"`
+	Blocked            bool     `json:"blocked"`
+	Durations
+}
+
+type DueTasks struct {
+	Overdue  []Task `json:"overdue"`
+	Upcoming []Task `json:"upcoming"`
 }
 
 type Session struct {
 	Id       uint64 `json:"id"`
+	TaskId   uint64 `json:"taskId"`
 	Duration uint64 `json:"duration"`
 	Date     uint64 `json:"date"`
+	Note     string `json:"note"`
+}
+
+type StatusChange struct {
+	Status string `json:"status"`
+	Date   uint64 `json:"date"`
+}
+
+type Milestone struct {
+	Id           uint64   `json:"id"`
+	ProjectId    uint64   `json:"projectId"`
+	Name         string   `json:"name"`
+	TargetDate   uint64   `json:"targetDate"`
+	Tasks        []uint64 `json:"tasks"`
+	Completeness float32  `json:"completeness"`
+}
+
+type Timer struct {
+	ProjectId uint64 `json:"projectId"`
+	Started   uint64 `json:"started"`
+	Resumed   uint64 `json:"resumed"`
+	Elapsed   uint64 `json:"elapsed"`
+	Running   bool   `json:"running"`
+}
+
+// Number of seconds measured by the timer at the given point in time
+func (t Timer) ElapsedAt(now time.Time) uint64 {
+	elapsed := t.Elapsed
+	if t.Running && uint64(now.Unix()) > t.Resumed {
+		elapsed += uint64(now.Unix()) - t.Resumed
+	}
+	return elapsed
 }
 
 type Project struct {
-	Id            uint64    `json:"id"`
-	Title         string    `json:"title"`
-	Description   string    `json:"description"`
-	Tags          []uint64  `json:"tags"`
-	DurationTotal uint64    `json:"durationTotal"`
-	DurationMonth uint64    `json:"durationMonth"`
-	DurationWeek  uint64    `json:"durationWeek"`
-	Completeness  float32   `json:"completeness"`
-	Tasks         []Task    `json:"tasks"`
-	Sessions      []Session `json:"sessions"`
+	Id            uint64         `json:"id"`
+	Title         string         `json:"title"`
+	Description   string         `json:"description"`
+	ParentId      uint64         `json:"parentId"`
+	Status        string         `json:"status"`
+	StatusHistory []StatusChange `json:"statusHistory"`
+	Tags          []uint64       `json:"tags"`
+	DurationTotal uint64         `json:"durationTotal"`
+	DurationMonth uint64         `json:"durationMonth"`
+	DurationWeek  uint64         `json:"durationWeek"`
+	Completeness  float32        `json:"completeness"`
+	Tasks         []Task         `json:"tasks"`
+	Sessions      []Session      `json:"sessions"`
+	Milestones    []Milestone    `json:"milestones"`
+	Goals         []Goal         `json:"goals"`
+}
+
+type TaskEstimate struct {
+	TaskId   uint64 `json:"taskId"`
+	Title    string `json:"title"`
+	Done     bool   `json:"done"`
+	Estimate uint64 `json:"estimate"`
+	Logged   uint64 `json:"logged"`
+}
+
+type ProjectEstimate struct {
+	ProjectId  uint64         `json:"projectId"`
+	Title      string         `json:"title"`
+	Estimate   uint64         `json:"estimate"`
+	Logged     uint64         `json:"logged"`
+	Unassigned uint64         `json:"unassigned"`
+	Tasks      []TaskEstimate `json:"tasks"`
+}
+
+type ProjectTotal struct {
+	ProjectId uint64 `json:"projectId"`
+	Title     string `json:"title"`
+	Duration  uint64 `json:"duration"`
+}
+
+type RangeReport struct {
+	From          uint64         `json:"from"`
+	To            uint64         `json:"to"`
+	DurationTotal uint64         `json:"durationTotal"`
+	Projects      []ProjectTotal `json:"projects"`
+}
+
+// Time bucket, project or tag of a report; Start is set for time buckets
+type ReportGroup struct {
+	Id               uint64 `json:"id"`
+	Label            string `json:"label"`
+	Start            uint64 `json:"start"`
+	Duration         uint64 `json:"duration"`
+	NumberOfSessions uint64 `json:"numSessions"`
+}
+
+type Report struct {
+	From             uint64        `json:"from"`
+	To               uint64        `json:"to"`
+	GroupBy          string        `json:"groupBy"`
+	DurationTotal    uint64        `json:"durationTotal"`
+	NumberOfSessions uint64        `json:"numSessions"`
+	Groups           []ReportGroup `json:"groups"`
 }
 
 type Response struct {
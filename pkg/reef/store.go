@@ -0,0 +1,79 @@
+//------------------------------------------------------------------------------
+// Author: Lukasz Janyst <lukasz@jany.st>
+// Date: 17.10.2026
+//
+// Licensed under the GPL 3 License, see the LICENSE file for details.
+//------------------------------------------------------------------------------
+
+package reef
+
+// Store holds the tags, projects, tasks, sessions and timers the controller
+// works on; Database keeps them in SQLite and MemoryStore keeps them in memory
+type Store interface {
+	CreateTag(name string, color string, parentId uint64) (uint64, error)
+	DeleteTag(id uint64) ([]uint64, error)
+	EditTag(id uint64, newName, newColor string) error
+	MoveTag(id, parentId uint64) (uint64, error)
+	GetTagById(id uint64) (Tag, error)
+	GetTagList() ([]Tag, error)
+	GetTagChildIds(id uint64) ([]uint64, error)
+	GetTagAncestorIds(id uint64) ([]uint64, error)
+	GetTagIdsByProjectId(id uint64) ([]uint64, error)
+
+	CreateProject(title, description string, tags []uint64, parentId uint64) (uint64, error)
+	DeleteProject(id uint64) ([]uint64, error)
+	EditProject(id uint64, title, description string, tags []uint64) ([]uint64, error)
+	MoveProject(id, parentId uint64) (uint64, error)
+	SetProjectStatus(id uint64, status string) error
+	GetProjectById(id uint64) (Project, error)
+	GetSummaryById(id uint64) (Summary, error)
+	GetSummaryList() ([]Summary, error)
+	GetSummaryTree() ([]SummaryNode, error)
+	GetProjectChildIds(id uint64) ([]uint64, error)
+	GetProjectAncestorIds(id uint64) ([]uint64, error)
+
+	AddTask(projectId, parentId uint64, details TaskDetails) error
+	DeleteTask(id uint64) (uint64, error)
+	EditTask(id uint64, details TaskDetails) (uint64, error)
+	ToggleTask(id uint64, cascade bool) (uint64, error)
+	ReorderTask(id, targetId uint64, after bool) (uint64, error)
+	AddTaskTag(taskId, tagId uint64) (uint64, error)
+	RemoveTaskTag(taskId, tagId uint64) (uint64, error)
+	LinkTasks(taskId, blockerId uint64) (uint64, error)
+	UnlinkTasks(taskId, blockerId uint64) (uint64, error)
+	GetSubtaskTagIds(id uint64) ([]uint64, error)
+	GetDependentProjectIds(id uint64) ([]uint64, error)
+	GetDueTasks(days uint64) (DueTasks, error)
+
+	CreateMilestone(projectId uint64, name string, targetDate uint64) error
+	EditMilestone(id uint64, name string, targetDate uint64) (uint64, error)
+	DeleteMilestone(id uint64) (uint64, error)
+	AssignMilestone(taskId, milestoneId uint64) (uint64, error)
+
+	CreateGoal(projectId, tagId uint64, period string, minimum, maximum uint64) error
+	EditGoal(id uint64, period string, minimum, maximum uint64) (uint64, uint64, error)
+	DeleteGoal(id uint64) (uint64, uint64, error)
+
+	AddSession(projectId, taskId, duration, date uint64, note string) error
+	EditSession(id, taskId, duration, date uint64, note string) (uint64, error)
+	DeleteSession(id uint64) (uint64, error)
+
+	GetTimer(projectId uint64) (Timer, error)
+	GetTimerList() ([]Timer, error)
+	StartTimer(projectId uint64) error
+	PauseTimer(projectId uint64) error
+	StopTimer(projectId uint64) (uint64, error)
+
+	GetEstimateReport(projectId uint64) ([]ProjectEstimate, error)
+	GetRangeReport(from, to uint64, period string, offset int) (RangeReport, error)
+	QueryReport(from, to uint64, period string, offset int, projectIds, tagIds []uint64,
+		groupBy string) (Report, error)
+
+	// Run fn so that either all or none of the changes it makes are kept
+	Batch(fn func(store Store) error) error
+}
+
+var (
+	_ Store = (*Database)(nil)
+	_ Store = (*MemoryStore)(nil)
+)
@@ -0,0 +1,316 @@
+//------------------------------------------------------------------------------
+// Author: Lukasz Janyst <lukasz@jany.st>
+// Date: 17.10.2026
+//
+// Licensed under the GPL 3 License, see the LICENSE file for details.
+//------------------------------------------------------------------------------
+
+package reef
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// Run the same sequence of operations against a store and record the results
+// and the errors of every one of them; some creations succeed after failed
+// ones to check that the failures don't use up any ids
+func runStoreScenario(store Store, now uint64) []string {
+	log := []string{}
+	record := func(name string, result interface{}, err error) {
+		data, _ := json.Marshal(result)
+		log = append(log, fmt.Sprintf("%s: %s %v", name, data, err))
+	}
+	dump := func(name string) {
+		tags, err := store.GetTagList()
+		record(name+" tags", tags, err)
+		tree, err := store.GetSummaryTree()
+		record(name+" tree", tree, err)
+		summaries, _ := store.GetSummaryList()
+		for _, summary := range summaries {
+			project, err := store.GetProjectById(summary.Id)
+			for i := range project.StatusHistory {
+				project.StatusHistory[i].Date = 0
+			}
+			record(fmt.Sprintf("%s project %d", name, summary.Id), project, err)
+		}
+		due, err := store.GetDueTasks(7)
+		record(name+" due", due, err)
+		estimates, err := store.GetEstimateReport(0)
+		record(name+" estimates", estimates, err)
+		rangeReport, err := store.GetRangeReport(now-100*86400, now+1, "", 0)
+		record(name+" range", rangeReport, err)
+		for _, groupBy := range []string{GroupByDay, GroupByWeek, GroupByMonth,
+			GroupByProject, GroupByTag} {
+			report, err := store.QueryReport(now-100*86400, now+1, "", 0, nil, nil, groupBy)
+			record(name+" report "+groupBy, report, err)
+		}
+		report, err := store.QueryReport(now-100*86400, now+1, "", 0, []uint64{1}, []uint64{1},
+			GroupByProject)
+		record(name+" filtered report", report, err)
+	}
+
+	id, err := store.CreateTag("work", "#ff0000", 0)
+	record("tag work", id, err)
+	id, err = store.CreateTag("dev", "#00ff00", 1)
+	record("tag dev", id, err)
+	id, err = store.CreateTag("ops", "#0000ff", 0)
+	record("tag ops", id, err)
+	id, err = store.CreateTag("work", "#ff0000", 0)
+	record("tag duplicate", id, err)
+	id, err = store.CreateTag("orphan", "#ff0000", 99)
+	record("tag missing parent", id, err)
+	id, err = store.CreateTag("qa", "#ffff00", 0)
+	record("tag after failures", id, err)
+	_, err = store.MoveTag(1, 2)
+	record("tag cycle", nil, err)
+	record("tag rename", nil, store.EditTag(3, "work", "#000000"))
+
+	id, err = store.CreateProject("Alpha", "first", []uint64{2}, 0)
+	record("project alpha", id, err)
+	id, err = store.CreateProject("Beta", "second", []uint64{2, 3, 2}, 1)
+	record("project beta", id, err)
+	id, err = store.CreateProject("Gamma", "third", []uint64{}, 2)
+	record("project gamma", id, err)
+	id, err = store.CreateProject("Alpha", "", []uint64{}, 0)
+	record("project duplicate", id, err)
+	id, err = store.CreateProject("Delta", "", []uint64{}, 0)
+	record("project after failures", id, err)
+	tags, err := store.EditProject(3, "Gamma", "changed", []uint64{3})
+	record("project edit", tags, err)
+	tags, err = store.EditProject(3, "Alpha", "", []uint64{})
+	record("project rename", tags, err)
+	id, err = store.MoveProject(1, 3)
+	record("project cycle", id, err)
+	id, err = store.MoveProject(3, 0)
+	record("project move", id, err)
+	record("project status", nil, store.SetProjectStatus(2, ProjectPaused))
+	record("project bad status", nil, store.SetProjectStatus(2, "lost"))
+
+	details := []TaskDetails{
+		{Title: "A", Estimate: 60},
+		{Title: "A1", Priority: 2},
+		{Title: "A2", Recurrence: "weekly", RecurrenceInterval: 1, DueDate: now + 2*86400},
+		{Title: "B", DueDate: now - 86400},
+	}
+	parents := []uint64{0, 1, 1, 0}
+	for i := range details {
+		record("task "+details[i].Title, nil, store.AddTask(1, parents[i], details[i]))
+	}
+	record("task wrong parent", nil, store.AddTask(2, 1, TaskDetails{Title: "C"}))
+	record("task dates", nil, store.AddTask(1, 0, TaskDetails{StartDate: 2, DueDate: 1}))
+	id, err = store.AddTaskTag(2, 3)
+	record("task tag", id, err)
+	id, err = store.AddTaskTag(2, 99)
+	record("task missing tag", id, err)
+	id, err = store.LinkTasks(4, 1)
+	record("task link", id, err)
+	id, err = store.LinkTasks(1, 4)
+	record("task cycle", id, err)
+	id, err = store.ReorderTask(4, 1, false)
+	record("task reorder", id, err)
+	record("task other project", nil, store.AddTask(2, 0, TaskDetails{Title: "D"}))
+	id, err = store.LinkTasks(5, 4)
+	record("task link other project", id, err)
+
+	record("milestone", nil, store.CreateMilestone(1, "M1", now+86400))
+	record("milestone other", nil, store.CreateMilestone(2, "M2", now))
+	id, err = store.AssignMilestone(1, 1)
+	record("milestone assign", id, err)
+	id, err = store.AssignMilestone(1, 2)
+	record("milestone wrong project", id, err)
+
+	record("goal project", nil, store.CreateGoal(1, 0, GoalWeek, 60, 0))
+	record("goal tag", nil, store.CreateGoal(0, 1, GoalMonth, 0, 600))
+	record("goal invalid", nil, store.CreateGoal(1, 1, GoalWeek, 60, 0))
+
+	record("session 1", nil, store.AddSession(1, 1, 30, now-3600, "one"))
+	record("session 2", nil, store.AddSession(2, 0, 45, now-40*86400, "two"))
+	record("session 3", nil, store.AddSession(3, 0, 20, now-60, ""))
+	record("session missing task", nil, store.AddSession(1, 99, 20, now, ""))
+	record("session wrong task", nil, store.AddSession(2, 1, 20, now, ""))
+	id, err = store.EditSession(1, 2, 35, now-7200, "edited")
+	record("session edit", id, err)
+
+	id, err = store.ToggleTask(3, false)
+	record("toggle recurring", id, err)
+	id, err = store.ToggleTask(1, true)
+	record("toggle cascade", id, err)
+
+	record("timer start", nil, store.StartTimer(1))
+	record("timer start again", nil, store.StartTimer(1))
+	record("timer pause", nil, store.PauseTimer(1))
+	record("timer pause again", nil, store.PauseTimer(1))
+	duration, err := store.StopTimer(1)
+	record("timer stop", duration, err)
+	duration, err = store.StopTimer(1)
+	record("timer stop again", duration, err)
+
+	ids, err := store.GetTagAncestorIds(2)
+	record("tag ancestors", ids, err)
+	ids, err = store.GetDependentProjectIds(1)
+	record("dependent projects", ids, err)
+	ids, err = store.GetSubtaskTagIds(1)
+	record("subtask tags", ids, err)
+	dump("populated")
+
+	id, err = store.DeleteTask(2)
+	record("delete task", id, err)
+	id, err = store.DeleteMilestone(1)
+	record("delete milestone", id, err)
+	_, _, err = store.DeleteGoal(2)
+	record("delete goal", nil, err)
+	id, err = store.DeleteSession(3)
+	record("delete session", id, err)
+	ids, err = store.DeleteTag(1)
+	record("delete tag", ids, err)
+	ids, err = store.DeleteProject(1)
+	record("delete project", ids, err)
+	dump("pruned")
+
+	_, err = store.GetProjectById(99)
+	record("missing project", nil, err)
+	_, err = store.GetTagById(99)
+	record("missing tag", nil, err)
+	_, err = store.DeleteTask(99)
+	record("missing task", nil, err)
+	_, err = store.EditMilestone(99, "", 0)
+	record("missing milestone", nil, err)
+	return log
+}
+
+// Run the scenario against a fresh SQLite database and the store and check
+// that both give the same results
+func compareWithDatabase(t *testing.T, store Store, now uint64) {
+	dir, err := ioutil.TempDir("", "reef-store")
+	if err != nil {
+		t.Fatalf("Unable to create a temporary directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := NewDatabase(&BackendOpts{DatabaseDirectory: dir})
+	if err != nil {
+		t.Fatalf("Unable to create the database: %s", err)
+	}
+
+	expected := runStoreScenario(db, now)
+	actual := runStoreScenario(store, now)
+	if len(expected) != len(actual) {
+		t.Fatalf("The scenarios have different lengths: %d %d", len(expected), len(actual))
+	}
+	for i := range expected {
+		if expected[i] != actual[i] {
+			t.Errorf("The store differs from the database:\n%s\n%s", expected[i], actual[i])
+		}
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	memory, err := NewMemoryStore(&BackendOpts{})
+	if err != nil {
+		t.Fatalf("Unable to create the memory store: %s", err)
+	}
+	compareWithDatabase(t, memory, uint64(time.Now().Unix()))
+}
+
+func TestMemoryStoreBatch(t *testing.T) {
+	memory, err := NewMemoryStore(&BackendOpts{})
+	if err != nil {
+		t.Fatalf("Unable to create the memory store: %s", err)
+	}
+	if _, err := memory.CreateProject("Alpha", "", []uint64{}, 0); err != nil {
+		t.Fatalf("Unable to create the project: %s", err)
+	}
+	before, _ := memory.GetProjectById(1)
+
+	// The other writers wait for the batch, so its failure doesn't roll back
+	// their changes
+	done := make(chan error, 1)
+	err = memory.Batch(func(store Store) error {
+		if _, err := store.CreateTag("work", "#ff0000", 0); err != nil {
+			return err
+		}
+		if _, err := store.EditProject(1, "Beta", "changed", []uint64{1}); err != nil {
+			return err
+		}
+		go func() {
+			_, err := memory.CreateProject("Gamma", "", []uint64{}, 0)
+			done <- err
+		}()
+		select {
+		case err := <-done:
+			t.Errorf("A writer ran during the batch: %v", err)
+			done <- err
+		case <-time.After(100 * time.Millisecond):
+		}
+		return fmt.Errorf("Injected failure")
+	})
+	if err == nil {
+		t.Fatalf("The batch ignored the failure")
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Unable to write after the batch: %s", err)
+	}
+	if _, err := memory.GetProjectById(2); err != nil {
+		t.Errorf("The change made after the batch is lost: %s", err)
+	}
+
+	tags, _ := memory.GetTagList()
+	after, _ := memory.GetProjectById(1)
+	if len(tags) != 0 || !reflect.DeepEqual(before, after) {
+		t.Errorf("The failed batch left changes behind: %v %+v", tags, after)
+	}
+	if id, err := memory.CreateTag("work", "#ff0000", 0); err != nil || id != 1 {
+		t.Errorf("Wrong tag after the failed batch: %d %v", id, err)
+	}
+}
+
+// Nothing refers to a deleted project or stays blocked by its tasks
+func TestDeleteProject(t *testing.T) {
+	db, cleanup := newTempDatabase(t)
+	defer cleanup()
+
+	memory, err := NewMemoryStore(&BackendOpts{})
+	if err != nil {
+		t.Fatalf("Unable to create the memory store: %s", err)
+	}
+
+	now := uint64(time.Now().Unix())
+	stores := map[string]Store{"database": db, "memory": memory}
+	for name, store := range stores {
+		steps := []func() error{
+			func() error { _, err := store.CreateTag("work", "#ff0000", 0); return err },
+			func() error { _, err := store.CreateProject("Alpha", "", []uint64{}, 0); return err },
+			func() error { _, err := store.CreateProject("Beta", "", []uint64{}, 0); return err },
+			func() error { return store.AddTask(1, 0, TaskDetails{Title: "A"}) },
+			func() error { return store.AddTask(2, 0, TaskDetails{Title: "B"}) },
+			func() error { _, err := store.AddTaskTag(1, 1); return err },
+			func() error { _, err := store.LinkTasks(2, 1); return err },
+			func() error { return store.AddSession(1, 1, 30, now-3600, "") },
+			func() error { _, err := store.DeleteProject(1); return err },
+		}
+		for i, step := range steps {
+			if err := step(); err != nil {
+				t.Fatalf("%s: unable to populate the store, step %d: %s", name, i, err)
+			}
+		}
+
+		project, err := store.GetProjectById(2)
+		if err != nil {
+			t.Fatalf("%s: unable to get the project: %s", name, err)
+		}
+		if len(project.Tasks) != 1 || project.Tasks[0].Blocked ||
+			len(project.Tasks[0].BlockedBy) != 0 {
+			t.Errorf("%s: wrong tasks after the deletion: %+v", name, project.Tasks)
+		}
+		report, err := store.QueryReport(0, now+1, "", 0, nil, nil, GroupByProject)
+		if err != nil || report.NumberOfSessions != 0 {
+			t.Errorf("%s: wrong sessions after the deletion: %+v %v", name, report, err)
+		}
+	}
+}
@@ -0,0 +1,270 @@
+//------------------------------------------------------------------------------
+// Author: Lukasz Janyst <lukasz@jany.st>
+// Date: 17.10.2026
+//
+// Licensed under the GPL 3 License, see the LICENSE file for details.
+//------------------------------------------------------------------------------
+
+package reef
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+var errInjected = errors.New("injected failure")
+
+// SQLite driver failing the n-th statement that is executed, counting from
+// one; the queries are not counted and zero disables the failures
+type faultyDriver struct {
+	mutex  sync.Mutex
+	failAt int
+	count  int
+}
+
+func (d *faultyDriver) arm(n int) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.failAt = n
+	d.count = 0
+}
+
+func (d *faultyDriver) executed() int {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return d.count
+}
+
+func (d *faultyDriver) fail() bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.count++
+	return d.count == d.failAt
+}
+
+func (d *faultyDriver) Open(name string) (driver.Conn, error) {
+	conn, err := (&sqlite3.SQLiteDriver{}).Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &faultyConn{conn, d}, nil
+}
+
+type faultyConn struct {
+	driver.Conn
+	driver *faultyDriver
+}
+
+func (c *faultyConn) Prepare(query string) (driver.Stmt, error) {
+	stmt, err := c.Conn.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	return &faultyStmt{stmt, c.driver}, nil
+}
+
+type faultyStmt struct {
+	driver.Stmt
+	driver *faultyDriver
+}
+
+func (s *faultyStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if s.driver.fail() {
+		return nil, errInjected
+	}
+	return s.Stmt.Exec(args)
+}
+
+var faulty = &faultyDriver{}
+
+func init() {
+	sql.Register("sqlite3-faulty", faulty)
+}
+
+// Create a database with a bit of everything and reopen it through the
+// faulty driver
+func newFaultyDatabase(t *testing.T, dir string) *Database {
+	db, err := NewDatabase(&BackendOpts{DatabaseDirectory: dir})
+	if err != nil {
+		t.Fatalf("Unable to create the database: %s", err)
+	}
+
+	now := uint64(time.Now().Unix())
+	steps := []func() error{
+		func() error { _, err := db.CreateTag("work", "#ff0000", 0); return err },
+		func() error { _, err := db.CreateTag("dev", "#00ff00", 1); return err },
+		func() error { _, err := db.CreateTag("ops", "#0000ff", 0); return err },
+		func() error { _, err := db.CreateProject("Alpha", "", []uint64{1, 2}, 0); return err },
+		func() error { _, err := db.CreateProject("Beta", "", []uint64{3}, 1); return err },
+		func() error { return db.AddTask(1, 0, TaskDetails{Title: "A", Estimate: 60}) },
+		func() error {
+			return db.AddTask(1, 1, TaskDetails{Title: "A1", Recurrence: "weekly",
+				RecurrenceInterval: 1, DueDate: now})
+		},
+		func() error { return db.AddTask(1, 0, TaskDetails{Title: "B"}) },
+		func() error { _, err := db.AddTaskTag(1, 2); return err },
+		func() error { _, err := db.AddTaskTag(2, 1); return err },
+		func() error { _, err := db.LinkTasks(3, 1); return err },
+		func() error { return db.CreateMilestone(1, "M1", now) },
+		func() error { _, err := db.AssignMilestone(1, 1); return err },
+		func() error { return db.CreateGoal(1, 0, GoalWeek, 60, 0) },
+		func() error { return db.CreateGoal(0, 1, GoalMonth, 0, 600) },
+		func() error { return db.AddSession(1, 1, 30, now-3600, "") },
+		func() error { return db.AddSession(2, 0, 45, now-7200, "") },
+		func() error { return db.StartTimer(1) },
+		func() error {
+			// Backdate the timer so that stopping it logs a session
+			query := "UPDATE timers SET started = started - 3600, resumed = resumed - 3600;"
+			_, err := db.db.Exec(query)
+			return err
+		},
+	}
+	for i, step := range steps {
+		if err := step(); err != nil {
+			t.Fatalf("Unable to populate the database, step %d: %s", i, err)
+		}
+	}
+
+	db.db.Close()
+	err = db.open("sqlite3-faulty", filepath.Join(dir, "reef.db"), sqliteDialect{})
+	if err != nil {
+		t.Fatalf("Unable to reopen the database: %s", err)
+	}
+	return db
+}
+
+// Dump the contents of all the tables
+func dumpDatabase(t *testing.T, db *Database) string {
+	tables := []string{"tags", "projects", "projectStatusHistory", "goals", "projectTags",
+		"tasks", "sessions", "timers", "taskDependencies", "taskTags", "milestones"}
+	var b strings.Builder
+	for _, table := range tables {
+		rows, err := db.db.Query("SELECT * FROM " + table + " ORDER BY 1, 2;")
+		if err != nil {
+			t.Fatalf("Unable to dump %s: %s", table, err)
+		}
+		columns, _ := rows.Columns()
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		for rows.Next() {
+			if err := rows.Scan(pointers...); err != nil {
+				t.Fatalf("Unable to scan %s: %s", table, err)
+			}
+			fmt.Fprintf(&b, "%s %v\n", table, values)
+		}
+		rows.Close()
+	}
+	return b.String()
+}
+
+// Fail every statement of the operations in turn and check that each failure
+// leaves the database untouched
+func TestTransactions(t *testing.T) {
+	operations := map[string]func(db *Database) error{
+		"CreateProject": func(db *Database) error {
+			_, err := db.CreateProject("Gamma", "", []uint64{1, 3}, 1)
+			return err
+		},
+		"EditProject": func(db *Database) error {
+			_, err := db.EditProject(1, "Alpha", "changed", []uint64{3})
+			return err
+		},
+		"DeleteProject": func(db *Database) error {
+			_, err := db.DeleteProject(1)
+			return err
+		},
+		"SetProjectStatus": func(db *Database) error {
+			return db.SetProjectStatus(1, ProjectPaused)
+		},
+		"DeleteTag": func(db *Database) error {
+			_, err := db.DeleteTag(1)
+			return err
+		},
+		"DeleteTask": func(db *Database) error {
+			_, err := db.DeleteTask(1)
+			return err
+		},
+		"ToggleTask": func(db *Database) error {
+			_, err := db.ToggleTask(1, true)
+			return err
+		},
+		"ReorderTask": func(db *Database) error {
+			_, err := db.ReorderTask(3, 1, false)
+			return err
+		},
+		"DeleteMilestone": func(db *Database) error {
+			_, err := db.DeleteMilestone(1)
+			return err
+		},
+		"StopTimer": func(db *Database) error {
+			_, err := db.StopTimer(1)
+			return err
+		},
+		"ImportArchive": func(db *Database) error {
+			archive, err := db.GetArchive()
+			if err != nil {
+				return err
+			}
+			for i := range archive.Tags {
+				archive.Tags[i].Name += " copy"
+			}
+			for i := range archive.Projects {
+				archive.Projects[i].Title += " copy"
+			}
+			_, err = db.ImportArchive(archive)
+			return err
+		},
+	}
+
+	for name, operation := range operations {
+		dir, err := ioutil.TempDir("", "reef-tx")
+		if err != nil {
+			t.Fatalf("Unable to create a temporary directory: %s", err)
+		}
+		db := newFaultyDatabase(t, dir)
+		before := dumpDatabase(t, db)
+
+		for n := 1; ; n++ {
+			faulty.arm(n)
+			err := operation(db)
+			injected := faulty.executed() >= n
+			faulty.arm(0)
+
+			if !injected {
+				if err != nil {
+					t.Errorf("%s failed: %s", name, err)
+				}
+				if n == 1 {
+					t.Errorf("%s did not execute any statements", name)
+				}
+				break
+			}
+			if err == nil {
+				t.Errorf("%s ignored the failure of statement %d", name, n)
+				break
+			}
+			if after := dumpDatabase(t, db); after != before {
+				t.Errorf("%s left changes behind after the failure of statement %d:\n%s\n%s",
+					name, n, before, after)
+				break
+			}
+		}
+
+		db.db.Close()
+		os.RemoveAll(dir)
+	}
+}
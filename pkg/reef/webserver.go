@@ -136,9 +136,9 @@ func (handler WebSocketHandler) ServeHTTP(w http.ResponseWriter, r *http.Request
 	writeMessages(conn, link)
 }
 
-func NewWebSocketHandler(db *Database) WebSocketHandler {
+func NewWebSocketHandler(controller *Controller) WebSocketHandler {
 	var webSocketHandler WebSocketHandler
-	webSocketHandler.controller = NewController(db)
+	webSocketHandler.controller = controller
 	return webSocketHandler
 }
 
@@ -183,15 +183,27 @@ func NewBasicAuthHandler(userMap map[string]string, handler http.Handler) BasicA
 }
 
 func RunWebServer(opts *ReefOpts) {
-	database, err := NewDatabase(opts.Backend.DatabaseDirectory)
+	database, err := NewDatabase(&opts.Backend)
 	if err != nil {
 		log.Fatal("Unable to initialize the database: ", err)
 	}
 
-	webSocketHandler := NewWebSocketHandler(database)
+	controller := NewController(database)
+	webSocketHandler := NewWebSocketHandler(controller)
 
 	assets := &fs.Index404Fs{Assets}
 	ui := http.FileServer(assets)
+	handlers := map[string]http.Handler{
+		"/":                      ui,
+		"/ws":                    webSocketHandler,
+		"/export/sessions.csv":   NewSessionExportHandler(database, "csv"),
+		"/export/sessions.json":  NewSessionExportHandler(database, "json"),
+		"/export/reef.json":      NewArchiveExportHandler(database),
+		"/import/reef.json":      NewArchiveImportHandler(database, controller),
+		"/calendar/tasks.ics":    NewCalendarHandler(database, "tasks"),
+		"/calendar/sessions.ics": NewCalendarHandler(database, "sessions"),
+	}
+
 	if opts.Web.EnableAuth {
 		authFile := opts.Web.HtpasswdFile
 		passwords, err := htpasswd.ParseHtpasswdFile(authFile)
@@ -202,12 +214,14 @@ func RunWebServer(opts *ReefOpts) {
 
 		log.Infof("Loaded authentication data from: %s", authFile)
 
-		http.Handle("/", NewBasicAuthHandler(passwords, ui))
-		http.Handle("/ws", NewBasicAuthHandler(passwords, webSocketHandler))
+		for path, handler := range handlers {
+			http.Handle(path, NewBasicAuthHandler(passwords, handler))
+		}
 
 	} else {
-		http.Handle("/", ui)
-		http.Handle("/ws", webSocketHandler)
+		for path, handler := range handlers {
+			http.Handle(path, handler)
+		}
 	}
 
 	var wg sync.WaitGroup
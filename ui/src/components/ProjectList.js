@@ -7,7 +7,7 @@
 
 import React, { Component } from 'react';
 import { Link } from 'react-router-dom';
-import { Table, Button, Icon, Tag, message } from 'antd';
+import { Table, Button, Icon, Tag, Select, message } from 'antd';
 import { connect } from 'react-redux';
 import sortBy from 'sort-by';
 
@@ -15,7 +15,7 @@ import { BACKEND_OPENED } from '../actions/backend';
 import TagPicker from './TagPicker';
 import ProjectAddModal from './ProjectAddModal';
 import { projectNew } from '../utils/backendActions';
-import { contains } from '../utils/helpers';
+import { contains, projectStatuses } from '../utils/helpers';
 
 const styles = {
   progress: {
@@ -38,7 +38,8 @@ class ProjectList extends Component {
   // The state
   //----------------------------------------------------------------------------
   state = {
-    selectedTags: []
+    selectedTags: [],
+    selectedStatuses: ['planned', 'active', 'done']
   }
 
   //----------------------------------------------------------------------------
@@ -62,13 +63,8 @@ class ProjectList extends Component {
     //--------------------------------------------------------------------------
     // Select records to render
     //--------------------------------------------------------------------------
-    const selectedLimbo = contains(1, this.state.selectedTags);
-    const selectedArchived = contains(2, this.state.selectedTags);
     const summaries = this.props.summaries.filter(elem => {
-      if (!selectedLimbo && contains(1, elem.tagIds)) {
-        return false;
-      }
-      if (!selectedArchived && contains(2, elem.tagIds)) {
+      if (!contains(elem.status, this.state.selectedStatuses)) {
         return false;
       }
       for (var i = 0; i < this.state.selectedTags.length; i++) {
@@ -132,6 +128,18 @@ class ProjectList extends Component {
           value={this.state.selectedTags}
           onChange={event => this.setState({selectedTags: event})}
         />
+        <Select
+          mode='multiple'
+          style={{width: '100%', marginTop: '0.5em'}}
+          placeholder='Select statuses'
+          value={this.state.selectedStatuses}
+          onChange={event => this.setState({selectedStatuses: event})}>
+          {
+            projectStatuses.map(status => (
+              <Select.Option key={status}>{status}</Select.Option>
+            ))
+          }
+        </Select>
         <div style={{marginTop: '1em'}}>
           {list}
         </div>
@@ -155,6 +163,7 @@ function mapStateToProps(state, ownProps) {
             .map(key => state.tags[key])
             .sort(sortBy('name')),
           tagIds: obj.tags,
+          status: obj.status,
           progress: obj.completeness
         };
       });
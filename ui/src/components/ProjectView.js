@@ -7,7 +7,7 @@
 
 import React, { Component } from 'react';
 import {
-  Card, Button, Tag, Tooltip, Empty, Popconfirm, message, Input
+  Card, Button, Tag, Tooltip, Empty, Popconfirm, message, Input, Select
 } from 'antd';
 import { connect } from 'react-redux';
 import { withRouter } from 'react-router';
@@ -18,10 +18,10 @@ import WorkSessionList from './WorkSessionList';
 import TagPicker from './TagPicker';
 import { BACKEND_OPENED } from '../actions/backend';
 import {
-  projectGet, projectDelete, projectEdit
+  projectGet, projectDelete, projectEdit, projectStatus
 } from '../utils/backendActions';
 import { projectSet } from '../actions/project';
-import { minutesToString } from '../utils/helpers';
+import { minutesToString, projectStatuses } from '../utils/helpers';
 
 const { TextArea } = Input;
 
@@ -243,6 +243,19 @@ class ProjectView extends Component {
           </div>
         </Tooltip>
         <div style={{float: 'right'}}>
+          <Select
+            size='small'
+            style={{width: '7em', marginRight: '0.5em'}}
+            value={this.props.status}
+            disabled={!this.props.connected}
+            onChange={status => projectStatus(this.props.id, status)
+                      .catch(error => message.error(error.message))}>
+            {
+              projectStatuses.map(status => (
+                <Select.Option key={status}>{status}</Select.Option>
+              ))
+            }
+          </Select>
           {
             this.props.tags
             .sort()
@@ -324,6 +337,7 @@ function mapStateToProps(state, ownProps) {
       title: p.title,
       description: p.description,
       tags: p.tags,
+      status: p.status,
       tagInfo: state.tags,
       durationTotal: p.durationTotal,
       durationMonth: p.durationMonth,
@@ -55,7 +55,9 @@ class TaskList extends Component {
   }
 
   editTask = (id, title, description, priority) => {
-    taskEdit(id, title, description, priority)
+    const task = this.props.tasks.find(t => t.id === id) || {};
+    taskEdit(id, title, description, priority, task.startDate, task.dueDate,
+             task.recurrence, task.recurrenceInterval, task.estimate)
       .catch(error => {
         setTimeout(() => message.error(error.message), 500);
       });
@@ -7,10 +7,17 @@
 
 import { backend } from './Backend';
 
-export function tagNew(name, color) {
+export function tagNew(name, color, parentId = 0) {
   return backend.sendMessage({
     action: 'TAG_NEW',
-    tagNewParams: {name,  color}
+    tagNewParams: {name,  color, parentId}
+  });
+}
+
+export function tagMove(id, parentId) {
+  return backend.sendMessage({
+    action: 'TAG_MOVE',
+    tagMoveParams: {id, parentId}
   });
 }
 
@@ -28,10 +35,30 @@ export function tagEdit(id, newName, newColor) {
   });
 }
 
-export function projectNew(name, tags, description) {
+export function projectNew(name, tags, description, parentId = 0) {
   return backend.sendMessage({
     action: 'PROJECT_NEW',
-    projectNewParams: {name, tags, description}
+    projectNewParams: {name, tags, description, parentId}
+  });
+}
+
+export function projectMove(id, parentId) {
+  return backend.sendMessage({
+    action: 'PROJECT_MOVE',
+    projectMoveParams: {id, parentId}
+  });
+}
+
+export function projectStatus(id, status) {
+  return backend.sendMessage({
+    action: 'PROJECT_STATUS',
+    projectStatusParams: {id, status}
+  });
+}
+
+export function summaryTree() {
+  return backend.sendMessage({
+    action: 'SUMMARY_TREE'
   });
 }
 
@@ -56,10 +83,14 @@ export function projectEdit(id, title, description, tags) {
   });
 }
 
-export function taskNew(projectId, title, description, priority) {
+export function taskNew(projectId, title, description, priority,
+                        startDate = 0, dueDate = 0, parentId = 0,
+                        recurrence = '', recurrenceInterval = 1, estimate = 0) {
   return backend.sendMessage({
     action: 'TASK_NEW',
-    taskNewParams: {projectId, title, description, priority}
+    taskNewParams: {projectId, parentId, title, description, priority,
+                    startDate, dueDate, recurrence, recurrenceInterval,
+                    estimate}
   });
 }
 
@@ -70,24 +101,125 @@ export function taskDelete(taskId) {
   });
 }
 
-export function taskToggle(taskId) {
+export function taskToggle(taskId, cascade = false) {
   return backend.sendMessage({
     action: 'TASK_TOGGLE',
-    taskToggleParams: taskId
+    taskToggleParams: {taskId, cascade}
   });
 }
 
-export function taskEdit(taskId, title, description, priority) {
+export function taskEdit(taskId, title, description, priority,
+                         startDate = 0, dueDate = 0,
+                         recurrence = '', recurrenceInterval = 1, estimate = 0) {
   return backend.sendMessage({
     action: 'TASK_EDIT',
-    taskEditParams: {taskId, title, description, priority}
+    taskEditParams: {taskId, title, description, priority, startDate, dueDate,
+                     recurrence, recurrenceInterval, estimate}
+  });
+}
+
+export function taskReorder(taskId, targetId, after = false) {
+  return backend.sendMessage({
+    action: 'TASK_REORDER',
+    taskReorderParams: {taskId, targetId, after}
+  });
+}
+
+export function taskTagAdd(taskId, tagId) {
+  return backend.sendMessage({
+    action: 'TASK_TAG_ADD',
+    taskTagAddParams: {taskId, tagId}
+  });
+}
+
+export function taskTagRemove(taskId, tagId) {
+  return backend.sendMessage({
+    action: 'TASK_TAG_REMOVE',
+    taskTagRemoveParams: {taskId, tagId}
+  });
+}
+
+export function taskLink(taskId, blockerId) {
+  return backend.sendMessage({
+    action: 'TASK_LINK',
+    taskLinkParams: {taskId, blockerId}
+  });
+}
+
+export function taskUnlink(taskId, blockerId) {
+  return backend.sendMessage({
+    action: 'TASK_UNLINK',
+    taskUnlinkParams: {taskId, blockerId}
+  });
+}
+
+export function taskListDue(days) {
+  return backend.sendMessage({
+    action: 'TASK_LIST_DUE',
+    taskListDueParams: days
+  });
+}
+
+export function milestoneNew(projectId, name, targetDate) {
+  return backend.sendMessage({
+    action: 'MILESTONE_NEW',
+    milestoneNewParams: {projectId, name, targetDate}
+  });
+}
+
+export function milestoneEdit(id, name, targetDate) {
+  return backend.sendMessage({
+    action: 'MILESTONE_EDIT',
+    milestoneEditParams: {id, name, targetDate}
   });
 }
 
-export function sessionNew(projectId, duration, date) {
+export function milestoneDelete(id) {
+  return backend.sendMessage({
+    action: 'MILESTONE_DELETE',
+    milestoneDeleteParams: id
+  });
+}
+
+export function milestoneAssign(taskId, milestoneId) {
+  return backend.sendMessage({
+    action: 'MILESTONE_ASSIGN',
+    milestoneAssignParams: {taskId, milestoneId}
+  });
+}
+
+export function goalNew(projectId, tagId, period, minimum, maximum) {
+  return backend.sendMessage({
+    action: 'GOAL_NEW',
+    goalNewParams: {projectId, tagId, period, minimum, maximum}
+  });
+}
+
+export function goalEdit(id, period, minimum, maximum) {
+  return backend.sendMessage({
+    action: 'GOAL_EDIT',
+    goalEditParams: {id, period, minimum, maximum}
+  });
+}
+
+export function goalDelete(id) {
+  return backend.sendMessage({
+    action: 'GOAL_DELETE',
+    goalDeleteParams: id
+  });
+}
+
+export function sessionNew(projectId, duration, date, taskId = 0, note = '') {
   return backend.sendMessage({
     action: 'SESSION_NEW',
-    sessionNewParams: {projectId, duration, date}
+    sessionNewParams: {projectId, taskId, duration, date, note}
+  });
+}
+
+export function sessionEdit(id, duration, date, taskId = 0, note = '') {
+  return backend.sendMessage({
+    action: 'SESSION_EDIT',
+    sessionEditParams: {id, taskId, duration, date, note}
   });
 }
 
@@ -97,3 +229,46 @@ export function sessionDelete(id) {
     sessionDeleteParams: id
   });
 }
+
+export function sessionStart(projectId) {
+  return backend.sendMessage({
+    action: 'SESSION_START',
+    sessionStartParams: projectId
+  });
+}
+
+export function sessionPause(projectId) {
+  return backend.sendMessage({
+    action: 'SESSION_PAUSE',
+    sessionPauseParams: projectId
+  });
+}
+
+export function sessionStop(projectId) {
+  return backend.sendMessage({
+    action: 'SESSION_STOP',
+    sessionStopParams: projectId
+  });
+}
+
+export function reportEstimates(projectId = 0) {
+  return backend.sendMessage({
+    action: 'REPORT_ESTIMATES',
+    reportEstimatesParams: projectId
+  });
+}
+
+export function reportRange(from, to, period = '', offset = 0) {
+  return backend.sendMessage({
+    action: 'REPORT_RANGE',
+    reportRangeParams: {from, to, period, offset}
+  });
+}
+
+export function reportQuery(from, to, groupBy, projectIds = [], tagIds = [],
+                            period = '', offset = 0) {
+  return backend.sendMessage({
+    action: 'REPORT_QUERY',
+    reportQueryParams: {from, to, period, offset, projectIds, tagIds, groupBy}
+  });
+}
@@ -68,3 +68,10 @@ export function minutesToString(minutes) {
 export function contains(elem, list) {
   return list.indexOf(elem) > -1;
 }
+
+//------------------------------------------------------------------------------
+// Project statuses known to the backend
+//------------------------------------------------------------------------------
+export const projectStatuses = [
+  'planned', 'active', 'paused', 'done', 'archived'
+];